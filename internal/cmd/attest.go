@@ -3,16 +3,22 @@ package cmd
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"slices"
+	"time"
 
-	intoto "github.com/in-toto/in-toto-golang/in_toto"
-	"github.com/puerco/bind/pkg/bundle"
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	protobom "github.com/protobom/protobom/pkg/sbom"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/stacklok/trusty-attest/internal/packages"
 	"github.com/stacklok/trusty-attest/pkg/sbom"
+	"github.com/stacklok/trusty-attest/pkg/sign"
+	"github.com/stacklok/trusty-attest/pkg/store"
 	"github.com/stacklok/trusty-attest/pkg/trusty"
 )
 
@@ -20,14 +26,87 @@ type attestOptions struct {
 	Bundle        bool
 	PredicateOnly bool
 	File          string
+	Recursive     bool
+	Split         bool
+	Include       []string
+	Exclude       []string
+	Revision      string
+	Offline       bool
+	Scope         string
+	EmitSBOM      string
+	Subjects      []string
+	Digests       []string
+	StatementType string
+	Keys          []string
+	Envelope      string
+	SBOM          string
+	Timestamp     string
+	Store         string
 }
 
+// statementVersions are the in-toto statement versions that can be output
+var statementVersions = []string{"v1", "v0.1"}
+
+// envelopeFormats are the envelopes the statement can be wrapped in
+var envelopeFormats = []string{"dsse"}
+
 // Validates the options in context with arguments
 func (ao *attestOptions) Validate() error {
 	if ao.Bundle && ao.PredicateOnly {
 		return fmt.Errorf("cannot define --bundle and --predicate-only at the same time")
 	}
-	return nil
+	if len(ao.Keys) > 0 && ao.PredicateOnly {
+		return fmt.Errorf("cannot sign with --key when outputting only the predicate")
+	}
+	if len(ao.Keys) > 1 && ao.Bundle {
+		return fmt.Errorf("bundles can only be signed with one --key")
+	}
+	if ao.Store != "" && ao.PredicateOnly {
+		return fmt.Errorf("cannot store bare predicates, --store requires a statement")
+	}
+	if ao.Envelope != "" {
+		if !slices.Contains(envelopeFormats, ao.Envelope) {
+			return fmt.Errorf("invalid envelope, must be one of %v", envelopeFormats)
+		}
+		if ao.Bundle || ao.PredicateOnly {
+			return fmt.Errorf("--envelope cannot be combined with --bundle or --predicate-only")
+		}
+	}
+	if ao.Split && !ao.Recursive {
+		return fmt.Errorf("--split requires --recursive")
+	}
+	if ao.SBOM != "" && (ao.Recursive || ao.Split || ao.Revision != "" || ao.EmitSBOM != "" || len(ao.Include) > 0) {
+		return fmt.Errorf("--sbom cannot be combined with --recursive, --split, --rev, --include or --emit-sbom")
+	}
+	if !slices.Contains(statementVersions, ao.StatementType) {
+		return fmt.Errorf("invalid statement version, must be one of %v", statementVersions)
+	}
+	for _, d := range ao.Digests {
+		if _, err := trusty.ParseSubjectDigest(d); err != nil {
+			return err
+		}
+	}
+	if _, err := ao.date(); err != nil {
+		return err
+	}
+	return sbom.ValidateScopeFilter(ao.Scope)
+}
+
+// date returns the time to record in the predicates: the one set in
+// --timestamp or, if not set, in SOURCE_DATE_EPOCH. It returns nil when
+// neither is set to stamp the current time.
+func (ao *attestOptions) date() (*time.Time, error) {
+	return sourceDate(ao.Timestamp)
+}
+
+// sourceDate parses the date set in a --timestamp flag, falling back to
+// SOURCE_DATE_EPOCH when the flag is blank. It returns nil when neither is
+// set.
+func sourceDate(timestamp string) (*time.Time, error) {
+	if timestamp != "" {
+		return trusty.ParseTimestamp(timestamp)
+	}
+	return trusty.SourceDateEpoch()
 }
 
 func (o *attestOptions) AddFlags(cmd *cobra.Command) {
@@ -36,7 +115,21 @@ func (o *attestOptions) AddFlags(cmd *cobra.Command) {
 		"bundle",
 		"b",
 		false,
-		"create a signed sigstore bundle (runs the oauth flow)",
+		"create a signed sigstore bundle (runs the oauth flow unless --key is set)",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&o.Keys,
+		"key",
+		[]string{},
+		"sign with the private key at path into a DSSE envelope (or a bundle with --bundle), can be repeated. Encrypted keys read the password from "+sign.PasswordEnvVar,
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Envelope,
+		"envelope",
+		"",
+		fmt.Sprintf("wrap the statement in an envelope, one of %v (signed with each --key, unsigned without keys)", envelopeFormats),
 	)
 
 	cmd.PersistentFlags().BoolVarP(
@@ -54,106 +147,574 @@ func (o *attestOptions) AddFlags(cmd *cobra.Command) {
 		"",
 		"write output to file path (default STDOUT)",
 	)
+
+	cmd.PersistentFlags().BoolVarP(
+		&o.Recursive,
+		"recursive",
+		"r",
+		false,
+		"look for manifests in all subdirectories, each one is attested as a component",
+	)
+
+	cmd.PersistentFlags().BoolVar(
+		&o.Split,
+		"split",
+		false,
+		"when recursing, output one attestation per component instead of a combined one",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&o.Include,
+		"include",
+		[]string{},
+		"only attest components in directories matching these globs",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Revision,
+		"rev",
+		"",
+		"git commit, tag or branch to attest, read from the repository without checking it out",
+	)
+
+	cmd.PersistentFlags().BoolVar(
+		&o.Offline,
+		"offline",
+		false,
+		"don't download the go.mod files of Go modules missing from the module cache from $GOPROXY, their dependencies are left unknown",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&o.Exclude,
+		"exclude",
+		append([]string{}, packages.DefaultExclude...),
+		"skip directories matching these globs",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Scope,
+		"scope",
+		sbom.ScopeAll,
+		fmt.Sprintf("dependency scopes to attest, one of %v", sbom.ScopeFilters),
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.EmitSBOM,
+		"emit-sbom",
+		"",
+		"also write the attested dependencies as an SBOM to file path (spdx or cyclonedx by extension), with --split one per component",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&o.Subjects,
+		"subject",
+		[]string{},
+		"file to use as attestation subject, its digests are computed (default the git commit)",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&o.Digests,
+		"subject-digest",
+		[]string{},
+		"subject expressed as name=algorithm:digest (eg myapp=sha256:2c26b4...)",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.SBOM,
+		"sbom",
+		"",
+		"attest the software described in an SBOM instead of a source directory, the subject is its root package",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.StatementType,
+		"statement-version",
+		"v1",
+		fmt.Sprintf("in-toto statement version to output, one of %v", statementVersions),
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Timestamp,
+		"timestamp",
+		"",
+		"date to record in the predicate, as seconds since the epoch or RFC 3339 (default $"+trusty.SourceDateEpochEnvVar+" or the current time)",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Store,
+		"store",
+		"",
+		"also append the attestations to the local store at path (a JSON lines file or directory)",
+	)
 }
 
 func addAttest(parentCmd *cobra.Command) {
 	opts := attestOptions{}
 	createCmd := &cobra.Command{
 		Short:             "generate Trusty attestations from source code",
-		Use:               "attest repository/path/",
-		Example:           fmt.Sprintf("%s attest repository/path/ ", appname),
+		Use:               "attest repository/path/ | --sbom sbom.json",
+		Example:           fmt.Sprintf("%s attest repository/path/ \n%s attest --rev v1.2.0 repository/path/\n%s attest --sbom myapp.spdx.json", appname, appname, appname),
 		SilenceUsage:      false,
 		SilenceErrors:     true,
 		PersistentPreRunE: initLogging,
 		RunE: func(_ *cobra.Command, args []string) error {
 			l := packages.NewLister()
 			ctx := context.Background()
-			if len(args) == 0 {
+			if len(args) == 0 && opts.SBOM == "" {
 				return fmt.Errorf("no directory specified")
 			}
+			if len(args) > 0 && opts.SBOM != "" {
+				return fmt.Errorf("cannot attest a directory and an SBOM at the same time")
+			}
 
 			if err := opts.Validate(); err != nil {
 				return err
 			}
 
-			nodelist, err := l.ReadPackages(ctx, args[0])
-			if err != nil {
-				return fmt.Errorf("reading packages: %w", err)
+			signers := []sign.Signer{}
+			for _, k := range opts.Keys {
+				s, err := sign.LoadKey(k)
+				if err != nil {
+					return err
+				}
+				signers = append(signers, s)
 			}
 
-			scorer := sbom.NewScorer()
-			results, err := scorer.ScoreNodeList(ctx, nodelist)
-			if err != nil {
-				return fmt.Errorf("scoring nodelist: %w", err)
-			}
+			l.Options.Recursive = opts.Recursive
+			l.Options.Include = opts.Include
+			l.Options.Exclude = opts.Exclude
+			l.Options.Revision = opts.Revision
+			l.Options.Offline = opts.Offline
 
-			pred, err := trusty.BuildPredicate(trusty.PredicateOpts{}, results)
-			if err != nil {
-				return fmt.Errorf("building attestation predicate: %w", err)
+			// Read the packages of the tree. When splitting, each
+			// component found gets its own attestation.
+			atts := []attestation{}
+			// The revision is resolved once for the packages and the
+			// project identity
+			var src *packages.Source
+			if opts.SBOM == "" {
+				s, err := l.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("reading packages: %w", err)
+				}
+				defer s.Close()
+				src = s
+			}
+			if opts.SBOM != "" {
+				att, err := opts.sbomAttestation(ctx)
+				if err != nil {
+					return err
+				}
+				atts = append(atts, *att)
+			} else if opts.Split {
+				components, err := l.ReadComponents(ctx, src)
+				if err != nil {
+					return fmt.Errorf("reading packages: %w", err)
+				}
+				for _, c := range components {
+					if opts.EmitSBOM != "" {
+						if err := emitSBOM(componentSBOMPath(opts.EmitSBOM, c.ID()), c.NodeList, c.Project.Name, &opts); err != nil {
+							return err
+						}
+					}
+					pred, err := scoreAndBuildPredicate(ctx, opts.scorerOptions(), c.NodeList, opts.predicateOpts(c.Project, path.Join(src.RepoPath(), c.Path)))
+					if err != nil {
+						return fmt.Errorf("attesting %s: %w", c.Path, err)
+					}
+					subjects, err := opts.subjects(c.Project)
+					if err != nil {
+						return err
+					}
+					atts = append(atts, attestation{
+						Subjects:  subjects,
+						Predicate: pred,
+					})
+				}
+			} else {
+				nodelist, err := l.ReadPackages(ctx, src)
+				if err != nil {
+					return fmt.Errorf("reading packages: %w", err)
+				}
+				project := src.Project()
+				if opts.EmitSBOM != "" {
+					if err := emitSBOM(opts.EmitSBOM, nodelist, project.Name, &opts); err != nil {
+						return err
+					}
+				}
+				pred, err := scoreAndBuildPredicate(ctx, opts.scorerOptions(), nodelist, opts.predicateOpts(project, src.RepoPath()))
+				if err != nil {
+					return err
+				}
+				subjects, err := opts.subjects(project)
+				if err != nil {
+					return err
+				}
+				atts = append(atts, attestation{
+					Subjects:  subjects,
+					Predicate: pred,
+				})
 			}
 
 			var f io.Writer
 			if opts.File != "" {
-				f, err = os.Create(opts.File)
+				out, err := os.Create(opts.File)
 				if err != nil {
 					return fmt.Errorf("opening file: %w", err)
 				}
-				defer f.(*os.File).Close()
+				defer out.Close()
+				f = out
 			} else {
 				f = os.Stdout
 			}
 
-			b := bytes.Buffer{}
-			enc := json.NewEncoder(&b)
-			enc.SetIndent("", "  ")
-			enc.SetEscapeHTML(false)
+			var st *store.LocalStore
+			if opts.Store != "" {
+				st = store.NewLocal(opts.Store)
+				if !opts.Bundle && len(signers) == 0 {
+					logrus.Warn("storing unsigned attestations, sign them with --bundle or --key to keep a verifiable history")
+				}
+			}
 
-			if opts.PredicateOnly {
-				if err := enc.Encode(pred); err != nil {
-					return fmt.Errorf("encoding predicate: %w", err)
+			for _, att := range atts {
+				b := bytes.Buffer{}
+				if err := writeAttestation(ctx, &b, &opts, signers, &att); err != nil {
+					return err
+				}
+				if st != nil {
+					if err := storeAttestation(st, b.Bytes()); err != nil {
+						return err
+					}
 				}
 				if _, err := b.WriteTo(f); err != nil {
-					return fmt.Errorf("writing predicate: %w", err)
+					return fmt.Errorf("writing attestation: %w", err)
 				}
-				return nil
 			}
+			return nil
+		},
+	}
+	opts.AddFlags(createCmd)
+	parentCmd.AddCommand(createCmd)
+}
 
-			// Create the attestation
-			att, err := trusty.Attest([]intoto.Subject{}, pred)
+// attestation is a predicate and the subjects it attests
+type attestation struct {
+	Subjects  []*intotov1.ResourceDescriptor
+	Predicate *trusty.Predicate
+}
 
-			if err := enc.Encode(att); err != nil {
-				return fmt.Errorf("encoding attestation: %w", err)
-			}
+// subjects returns the attestation subjects of a project: the files and
+// digests set in the options or, if none are set, the git commit of the
+// project.
+func (o *attestOptions) subjects(p *packages.Project) ([]*intotov1.ResourceDescriptor, error) {
+	subjects, err := o.flagSubjects()
+	if err != nil {
+		return nil, err
+	}
+	if len(subjects) > 0 || p.Commit == "" {
+		return subjects, nil
+	}
 
-			if !opts.Bundle {
-				if _, err := b.WriteTo(f); err != nil {
-					return err
-				}
-				return nil
-			}
+	return []*intotov1.ResourceDescriptor{
+		{Name: p.Name, Uri: p.Repository, Digest: map[string]string{"gitCommit": p.Commit}},
+	}, nil
+}
 
-			// If bundle, bind the attestation, this kicks off the
-			// sigstore flow
-			signer := bundle.NewSigner()
-			bndl, err := signer.SignAndBind(ctx, b.Bytes())
-			if err != nil {
-				return fmt.Errorf("signing and binding attestation: %w", err)
-			}
+// flagSubjects returns the subjects set with --subject and --subject-digest
+func (o *attestOptions) flagSubjects() ([]*intotov1.ResourceDescriptor, error) {
+	subjects := []*intotov1.ResourceDescriptor{}
+	for _, path := range o.Subjects {
+		s, err := trusty.FileSubject(path)
+		if err != nil {
+			return nil, fmt.Errorf("computing subject: %w", err)
+		}
+		subjects = append(subjects, s)
+	}
+	for _, d := range o.Digests {
+		s, err := trusty.ParseSubjectDigest(d)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, s)
+	}
+	return subjects, nil
+}
 
-			// Clear the buffer
-			b.Truncate(0)
+// sbomAttestation scores the packages in the SBOM set in the options and
+// returns the attestation of the software it describes. Unless set with
+// the subject flags, the subjects are the digests of the SBOM root packages.
+func (o *attestOptions) sbomAttestation(ctx context.Context) (*attestation, error) {
+	f, err := os.Open(o.SBOM)
+	if err != nil {
+		return nil, fmt.Errorf("opening SBOM: %w", err)
+	}
+	defer f.Close()
 
-			if err := enc.Encode(bndl); err != nil {
-				return fmt.Errorf("encoding bundle: %w", err)
-			}
+	doc, err := sbom.ParseSBOM(f)
+	if err != nil {
+		return nil, err
+	}
+	// ParseSBOM rejects documents whose root elements don't resolve to
+	// nodes, checked again here as the first root names the predicate
+	roots := doc.GetNodeList().GetRootNodes()
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("%s does not describe any package", o.SBOM)
+	}
 
-			if _, err := b.WriteTo(f); err != nil {
-				return err
+	scorer := sbom.NewScorer()
+	scorer.Options = o.scorerOptions()
+	results, err := scorer.ScoreDocument(ctx, doc)
+	if err != nil {
+		return nil, fmt.Errorf("scoring %s: %w", o.SBOM, err)
+	}
+
+	input, err := trusty.FileSubject(o.SBOM)
+	if err != nil {
+		return nil, err
+	}
+	date, err := o.date()
+	if err != nil {
+		return nil, err
+	}
+	pred, err := trusty.BuildPredicate(trusty.PredicateOpts{
+		Package:    sbom.NodePackageInfo(roots[0]),
+		Endpoint:   scorer.Endpoint(),
+		Input:      &trusty.InputInfo{Type: trusty.InputSBOM, Path: packages.RepoPath(o.SBOM), Digest: input.GetDigest()},
+		Ecosystems: sbom.Ecosystems(doc.GetNodeList()),
+		Options:    o.scanOptions(),
+		Date:       date,
+	}, results)
+	if err != nil {
+		return nil, fmt.Errorf("building attestation predicate: %w", err)
+	}
+
+	subjects, err := o.flagSubjects()
+	if err != nil {
+		return nil, err
+	}
+	if len(subjects) == 0 {
+		for _, n := range roots {
+			if s := sbom.NodeSubject(n); s != nil {
+				subjects = append(subjects, s)
 			}
+		}
+	}
+	if len(subjects) == 0 {
+		return nil, fmt.Errorf("the packages described in the SBOM have no digests, set the subject with --subject or --subject-digest")
+	}
 
-			return nil
+	return &attestation{Subjects: subjects, Predicate: pred}, nil
+}
+
+// projectPredicateOpts returns the predicate options that record the
+// identity of the attested project in the predicate metadata
+func projectPredicateOpts(p *packages.Project) trusty.PredicateOpts {
+	opts := trusty.PredicateOpts{
+		Package: trusty.PackageInfo{
+			Package:     p.Name,
+			Version:     p.Version,
+			Identifiers: map[string]string{},
 		},
 	}
-	opts.AddFlags(createCmd)
-	parentCmd.AddCommand(createCmd)
+	if p.Ecosystem != "" {
+		opts.Package.Ecosystem = p.Ecosystem.ToTrusty().AsString()
+	}
+	if p.Purl != "" {
+		opts.Package.Identifiers["purl"] = p.Purl
+	}
+	if p.Repository != "" || p.Commit != "" {
+		opts.Source = &trusty.SourceInfo{
+			Repository: p.Repository,
+			Commit:     p.Commit,
+			Tag:        p.Tag,
+		}
+	}
+	return opts
+}
+
+// predicateOpts returns the predicate options of a project read from a
+// directory, recording the input and scan options in the metadata. The
+// path of the directory is relative to the repository root.
+func (o *attestOptions) predicateOpts(p *packages.Project, path string) trusty.PredicateOpts {
+	opts := projectPredicateOpts(p)
+	opts.Input = &trusty.InputInfo{Type: trusty.InputDirectory, Path: path}
+	opts.Options = o.scanOptions()
+	// The date is checked when validating the options
+	opts.Date, _ = o.date()
+	return opts
+}
+
+// scanOptions returns the options that selected the packages attested,
+// to record them in the predicate. Transitive dependencies are always
+// attested.
+func (o *attestOptions) scanOptions() *trusty.ScanOptions {
+	so := &trusty.ScanOptions{
+		Scope:      o.Scope,
+		Transients: true,
+		Recursive:  o.Recursive,
+		Revision:   o.Revision,
+	}
+	if o.Recursive {
+		so.Include = o.Include
+		so.Exclude = o.Exclude
+	}
+	return so
+}
+
+// scorerOptions returns the options that control which packages are scored
+func (o *attestOptions) scorerOptions() sbom.Options {
+	return sbom.Options{Scope: o.Scope}
+}
+
+// scoreAndBuildPredicate scores the packages in a node list and returns
+// the predicate with the results
+func scoreAndBuildPredicate(ctx context.Context, scorerOpts sbom.Options, nodelist *protobom.NodeList, predOpts trusty.PredicateOpts) (*trusty.Predicate, error) {
+	scorer := sbom.NewScorer()
+	scorer.Options = scorerOpts
+	results, err := scorer.ScoreNodeList(ctx, nodelist)
+	if err != nil {
+		return nil, fmt.Errorf("scoring nodelist: %w", err)
+	}
+
+	predOpts.Endpoint = scorer.Endpoint()
+	predOpts.Ecosystems = sbom.Ecosystems(nodelist)
+
+	pred, err := trusty.BuildPredicate(predOpts, results)
+	if err != nil {
+		return nil, fmt.Errorf("building attestation predicate: %w", err)
+	}
+	return pred, nil
+}
+
+// encodeStatement wraps the attestation predicate in an in-toto statement
+// of the requested version and writes its JSON encoding to b
+func encodeStatement(b *bytes.Buffer, version string, att *attestation) error {
+	if len(att.Subjects) == 0 {
+		logrus.Warn("the attestation has no subjects, use --subject to bind it to an artifact")
+	}
+
+	var statement any
+	if version == "v0.1" {
+		st, err := trusty.AttestV01(att.Subjects, att.Predicate)
+		if err != nil {
+			return fmt.Errorf("creating attestation: %w", err)
+		}
+		statement = st
+	} else {
+		st, err := trusty.Attest(att.Subjects, att.Predicate)
+		if err != nil {
+			return fmt.Errorf("creating attestation: %w", err)
+		}
+		statement = st
+	}
+
+	data, err := trusty.CanonicalJSON(statement)
+	if err != nil {
+		return fmt.Errorf("encoding attestation: %w", err)
+	}
+	b.Write(data)
+	return nil
+}
+
+// writeAttestation writes the predicate to f, wrapped in an attestation and
+// signed bundle according to the options. When signers are set, the
+// statement is wrapped in an envelope signed by them instead of running the
+// sigstore flow.
+func writeAttestation(ctx context.Context, f io.Writer, opts *attestOptions, signers []sign.Signer, att *attestation) error {
+	b := bytes.Buffer{}
+
+	if opts.PredicateOnly {
+		data, err := trusty.CanonicalJSON(att.Predicate)
+		if err != nil {
+			return fmt.Errorf("encoding predicate: %w", err)
+		}
+		if _, err := f.Write(data); err != nil {
+			return fmt.Errorf("writing predicate: %w", err)
+		}
+		return nil
+	}
+
+	// Create the attestation
+	if err := encodeStatement(&b, opts.StatementType, att); err != nil {
+		return err
+	}
+
+	if opts.Envelope != "" || len(signers) > 0 {
+		return writeEnvelope(ctx, f, signers, opts.Bundle, b.Bytes())
+	}
+
+	return writeStatement(ctx, f, b.Bytes(), opts.Bundle)
+}
+
+// writeStatement writes an encoded statement to f as is or, if asBundle is
+// set, signed in a sigstore bundle
+func writeStatement(ctx context.Context, f io.Writer, statement []byte, asBundle bool) error {
+	if !asBundle {
+		if _, err := f.Write(statement); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// If bundle, bind the attestation, this kicks off the
+	// sigstore flow
+	bndl, err := sign.Keyless(ctx, statement)
+	if err != nil {
+		return err
+	}
+
+	data, err := trusty.CanonicalJSON(bndl)
+	if err != nil {
+		return fmt.Errorf("encoding bundle: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// storeAttestation appends an attestation document to the store
+func storeAttestation(st store.Store, doc []byte) error {
+	entry, err := store.NewEntry(doc)
+	if err != nil {
+		return fmt.Errorf("indexing attestation: %w", err)
+	}
+	if err := st.Append(entry); err != nil {
+		if errors.Is(err, store.ErrDuplicate) {
+			logrus.Warnf("attestation %s is already stored", entry.ID)
+			return nil
+		}
+		return fmt.Errorf("storing attestation: %w", err)
+	}
+	logrus.Infof("attestation %s appended to the store", entry.ID)
+	return nil
+}
+
+// writeEnvelope wraps the statement in a DSSE envelope signed by each of
+// the signers and writes it to f, as is or, if asBundle is set, in a
+// sigstore bundle.
+func writeEnvelope(ctx context.Context, f io.Writer, signers []sign.Signer, asBundle bool, statement []byte) error {
+	env, err := sign.Envelope(ctx, sign.PayloadType, statement, signers...)
+	if err != nil {
+		return err
+	}
+
+	var doc any = env
+	if asBundle {
+		bndl, err := sign.Bundle(env)
+		if err != nil {
+			return fmt.Errorf("creating bundle: %w", err)
+		}
+		doc = bndl
+	}
+
+	data, err := trusty.CanonicalJSON(doc)
+	if err != nil {
+		return fmt.Errorf("encoding envelope: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-sdk-go/pkg/types"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// trustyServer fakes the Trusty API, every package scores 7.5
+func trustyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	score := 7.5
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(&types.Reply{
+			PackageName: r.URL.Query().Get("package_name"),
+			PackageType: r.URL.Query().Get("package_type"),
+			Summary:     types.ScoreSummary{Score: &score},
+			Provenance:  &types.Provenance{Score: 3},
+		}); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// checkout writes the files of the attest fixture to the web directory of
+// a new git repository at dir, committed with a fixed date so every
+// checkout gets the same commit.
+func checkout(t *testing.T, dir string) string {
+	t.Helper()
+	project := filepath.Join(dir, "web")
+	if err := os.MkdirAll(project, os.FileMode(0o755)); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"package.json", "package-lock.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "attest", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(project, name), data, os.FileMode(0o644)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.AddGlob("web/*"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(1704067200, 0)}
+	if _, err := wt.Commit("fixture", &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+// runAttest runs trusty attest with args and returns the attestation
+func runAttest(t *testing.T, args ...string) []byte {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "attestation.json")
+	parent := &cobra.Command{Use: appname}
+	addAttest(parent)
+	parent.SetArgs(append([]string{"attest", "--file", out}, args...))
+	if err := parent.Execute(); err != nil {
+		t.Fatalf("running attest: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestAttestReproducible(t *testing.T) {
+	srv := trustyServer(t)
+	t.Setenv("TRUSTY_ENDPOINT", srv.URL)
+	t.Setenv(trusty.SourceDateEpochEnvVar, "1704067200")
+
+	// Two checkouts of the same commit in different directories
+	first := runAttest(t, checkout(t, t.TempDir()))
+	second := runAttest(t, checkout(t, t.TempDir()))
+	if !bytes.Equal(first, second) {
+		t.Fatalf("attestations differ:\n%s\n%s", first, second)
+	}
+
+	st, err := trusty.ParseStatement(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := st.Predicate.Metadata
+	if md.Input == nil || md.Input.Path != "web" {
+		t.Errorf("expected the input path relative to the repository, got %+v", md.Input)
+	}
+	if want := time.Unix(1704067200, 0).UTC(); md.Date == nil || !md.Date.Equal(want) {
+		t.Errorf("expected the predicate dated %s, got %v", want, md.Date)
+	}
+	if len(st.Predicate.Packages) == 0 {
+		t.Error("no packages attested")
+	}
+}
+
+// packageVersions indexes the versions of the attested packages by name
+func packageVersions(st *trusty.Statement) map[string][]string {
+	ret := map[string][]string{}
+	for _, p := range st.Predicate.Packages {
+		ret[p.Package] = append(ret[p.Package], p.Version)
+	}
+	return ret
+}
+
+func TestAttestRevision(t *testing.T) {
+	srv := trustyServer(t)
+	t.Setenv("TRUSTY_ENDPOINT", srv.URL)
+	t.Setenv(trusty.SourceDateEpochEnvVar, "1704067200")
+
+	// The fixture is committed first, then b is upgraded in a second
+	// commit and a third version is left uncommitted.
+	dir := t.TempDir()
+	project := checkout(t, dir)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := head.Hash().String()
+
+	lockfile := filepath.Join(project, "package-lock.json")
+	setB := func(version string) {
+		t.Helper()
+		data, err := os.ReadFile(lockfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = regexp.MustCompile(`("node_modules/b": \{ "version": )"[^"]+"`).ReplaceAll(data, []byte(`$1"`+version+`"`))
+		if err := os.WriteFile(lockfile, data, os.FileMode(0o644)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setB("2.1.0")
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("web/package-lock.json"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Unix(1704070800, 0)}
+	if _, err := wt.Commit("upgrade b", &git.CommitOptions{Author: sig, Committer: sig}); err != nil {
+		t.Fatal(err)
+	}
+	setB("2.2.0")
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		b      string
+		commit string
+	}{
+		{"worktree", nil, "2.2.0", ""},
+		{"head", []string{"--rev", "HEAD"}, "2.1.0", ""},
+		{"first", []string{"--rev", first}, "2.0.0", first},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := trusty.ParseStatement(runAttest(t, append(tc.args, project)...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// d depends on b@1.0.0 in every version of the tree
+			if v := packageVersions(st)["b"]; !reflect.DeepEqual(v, []string{"1.0.0", tc.b}) && !reflect.DeepEqual(v, []string{tc.b, "1.0.0"}) {
+				t.Errorf("expected b@%s and b@1.0.0, got %q", tc.b, v)
+			}
+			if tc.commit != "" && (st.Predicate.Metadata.Source == nil || st.Predicate.Metadata.Source.Commit != tc.commit) {
+				t.Errorf("expected the source commit %s, got %+v", tc.commit, st.Predicate.Metadata.Source)
+			}
+		})
+	}
+}
+
+func TestAttestSBOMWithoutRoots(t *testing.T) {
+	srv := trustyServer(t)
+	t.Setenv("TRUSTY_ENDPOINT", srv.URL)
+
+	// The document describes a package that is not in it
+	parent := &cobra.Command{Use: appname}
+	addAttest(parent)
+	parent.SetArgs([]string{
+		"attest", "--file", filepath.Join(t.TempDir(), "attestation.json"),
+		"--sbom", filepath.Join("testdata", "attest", "no-root.spdx.json"),
+	})
+	parent.SilenceUsage = true
+	err := parent.Execute()
+	if err == nil || !strings.Contains(err.Error(), "does not describe any package") {
+		t.Fatalf("expected an error about the missing root, got %v", err)
+	}
+}
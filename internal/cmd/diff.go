@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-attest/pkg/diff"
+	"github.com/stacklok/trusty-attest/pkg/display"
+	"github.com/stacklok/trusty-attest/pkg/sbom"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+type diffOptions struct {
+	OldPath string
+	NewPath string
+	Format  string
+}
+
+var diffFormats = []string{"term", "markdown", "json"}
+
+// Validate checks the options in context with arguments
+func (do *diffOptions) Validate() error {
+	if do.OldPath == "" || do.NewPath == "" {
+		return fmt.Errorf("two SBOMs are required to diff")
+	}
+	if !slices.Contains(diffFormats, do.Format) {
+		return fmt.Errorf("invalid format, must be one of %v", diffFormats)
+	}
+	return nil
+}
+
+func (do *diffOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(
+		&do.Format,
+		"format",
+		"f",
+		"term",
+		fmt.Sprintf("Output format, one of %v", diffFormats),
+	)
+}
+
+func addDiff(parentCmd *cobra.Command) {
+	opts := diffOptions{}
+	diffCmd := &cobra.Command{
+		Short:             "compare the dependency trust of two SBOMs",
+		Use:               "diff [flags] old.[spdx|cdx].json new.[spdx|cdx].json",
+		Example:           fmt.Sprintf("%s diff kubernetes-1.30.2.spdx.json kubernetes-1.30.3.spdx.json", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				opts.OldPath = args[0]
+				opts.NewPath = args[1]
+			}
+
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			scorer := sbom.NewScorer()
+
+			oldScores, err := scoreSBOMFile(ctx, scorer, opts.OldPath)
+			if err != nil {
+				return err
+			}
+
+			newScores, err := scoreSBOMFile(ctx, scorer, opts.NewPath)
+			if err != nil {
+				return err
+			}
+
+			report := diff.Compare(oldScores, newScores)
+
+			var renderer display.DiffRenderer
+			switch opts.Format {
+			case "term":
+				renderer = &display.TermRenderer{}
+			case "markdown":
+				renderer = &display.MarkdownRenderer{}
+			case "json":
+				renderer = &display.JSONRenderer{}
+			}
+
+			return renderer.DisplayDiff(os.Stdout, report)
+		},
+	}
+	opts.AddFlags(diffCmd)
+	parentCmd.AddCommand(diffCmd)
+}
+
+// scoreSBOMFile opens an SBOM and scores its dependencies
+func scoreSBOMFile(ctx context.Context, scorer *sbom.Scorer, path string) ([]trusty.PackageScore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening SBOM: %w", err)
+	}
+	defer f.Close()
+
+	results, err := scorer.ScoreSBOM(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("scoring %s: %w", path, err)
+	}
+	return results, nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	protoformats "github.com/protobom/protobom/pkg/formats"
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-attest/pkg/sbom"
+)
+
+type enrichOptions struct {
+	SbomPath string
+	File     string
+	Format   string
+}
+
+// sbomFormats maps the format names accepted in the command line to the
+// protobom formats used to write them
+var sbomFormats = map[string]protoformats.Format{
+	"cyclonedx": protoformats.CDX15JSON,
+	"spdx":      protoformats.SPDX23JSON,
+}
+
+// Validate checks the options in context with arguments
+func (eo *enrichOptions) Validate() error {
+	if eo.SbomPath == "" {
+		return fmt.Errorf("no SBOM specified")
+	}
+	if _, ok := sbomFormats[eo.Format]; !ok && eo.Format != "" {
+		return fmt.Errorf("invalid format, must be one of cyclonedx or spdx")
+	}
+	return nil
+}
+
+func (eo *enrichOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(
+		&eo.File,
+		"output",
+		"o",
+		"",
+		"write the enriched SBOM to file path (default STDOUT)",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&eo.Format,
+		"format",
+		"",
+		"format of the enriched SBOM, cyclonedx or spdx (default to output filename or input format)",
+	)
+}
+
+// OutputFormat returns the protobom format to write the enriched SBOM. If
+// not set in the options, we try to infer it from the output filename.
+// A blank format means the input format is preserved.
+func (eo *enrichOptions) OutputFormat() protoformats.Format {
+	return sbomFormatFromOptions(eo.Format, eo.File)
+}
+
+// sbomFormatFromOptions returns the protobom format from its name or
+// the file extension of path if blank.
+func sbomFormatFromOptions(name, path string) protoformats.Format {
+	if name != "" {
+		return sbomFormats[name]
+	}
+	switch {
+	case strings.HasSuffix(path, ".cdx.json"), strings.HasSuffix(path, ".cyclonedx.json"):
+		return sbomFormats["cyclonedx"]
+	case strings.HasSuffix(path, ".spdx.json"):
+		return sbomFormats["spdx"]
+	}
+	return ""
+}
+
+func addSBOMEnrich(parentCmd *cobra.Command) {
+	opts := enrichOptions{}
+	enrichCmd := &cobra.Command{
+		Short:             "embed Trusty scores in an SBOM",
+		Use:               "enrich [flags] sbom.[spdx|cdx].json",
+		Example:           fmt.Sprintf("%s sbom enrich in.spdx.json -o out.cdx.json", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.SbomPath = args[0]
+			}
+
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			s, err := os.Open(opts.SbomPath)
+			if err != nil {
+				return fmt.Errorf("opening SBOM: %w", err)
+			}
+			defer s.Close()
+
+			var f io.WriteCloser
+			if opts.File != "" {
+				f, err = os.Create(opts.File)
+				if err != nil {
+					return fmt.Errorf("opening file: %w", err)
+				}
+				defer f.Close()
+			} else {
+				f = os.Stdout
+			}
+
+			enricher := sbom.NewEnricher()
+			if err := enricher.EnrichSBOM(
+				context.Background(), s, f, opts.OutputFormat(),
+			); err != nil {
+				return fmt.Errorf("enriching SBOM: %w", err)
+			}
+			return nil
+		},
+	}
+	opts.AddFlags(enrichCmd)
+	parentCmd.AddCommand(enrichCmd)
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	protoformats "github.com/protobom/protobom/pkg/formats"
+	protobom "github.com/protobom/protobom/pkg/sbom"
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-attest/internal/packages"
+	"github.com/stacklok/trusty-attest/pkg/sbom"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+type generateOptions struct {
+	File      string
+	Format    string
+	Recursive bool
+	Include   []string
+	Exclude   []string
+	Revision  string
+	Offline   bool
+	Scope     string
+	Timestamp string
+}
+
+// Validate checks the options in context with arguments
+func (o *generateOptions) Validate() error {
+	if _, ok := sbomFormats[o.Format]; !ok && o.Format != "" {
+		return fmt.Errorf("invalid format, must be one of cyclonedx or spdx")
+	}
+	if _, err := sourceDate(o.Timestamp); err != nil {
+		return err
+	}
+	return sbom.ValidateScopeFilter(o.Scope)
+}
+
+func (o *generateOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(
+		&o.File,
+		"output",
+		"o",
+		"",
+		"write the SBOM to file path (default STDOUT)",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Format,
+		"format",
+		"",
+		"format of the SBOM, cyclonedx or spdx (default to output filename or spdx)",
+	)
+
+	cmd.PersistentFlags().BoolVarP(
+		&o.Recursive,
+		"recursive",
+		"r",
+		false,
+		"look for manifests in all subdirectories, each one is added as a component",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&o.Include,
+		"include",
+		[]string{},
+		"only read components in directories matching these globs",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Revision,
+		"rev",
+		"",
+		"git commit, tag or branch to read, without checking it out",
+	)
+
+	cmd.PersistentFlags().BoolVar(
+		&o.Offline,
+		"offline",
+		false,
+		"don't download the go.mod files of Go modules missing from the module cache from $GOPROXY, their dependencies are left unknown",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&o.Exclude,
+		"exclude",
+		append([]string{}, packages.DefaultExclude...),
+		"skip directories matching these globs",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Scope,
+		"scope",
+		sbom.ScopeAll,
+		fmt.Sprintf("dependency scopes to include, one of %v", sbom.ScopeFilters),
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&o.Timestamp,
+		"timestamp",
+		"",
+		"date to record in the SBOM, as seconds since the epoch or RFC 3339 (default $"+trusty.SourceDateEpochEnvVar+" or the current time)",
+	)
+}
+
+func addSBOMGenerate(parentCmd *cobra.Command) {
+	opts := generateOptions{}
+	generateCmd := &cobra.Command{
+		Short:             "generate an SBOM of the dependencies read from source code",
+		Use:               "generate [flags] repository/path/",
+		Example:           fmt.Sprintf("%s sbom generate --format cyclonedx repository/path/", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no directory specified")
+			}
+
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			l := packages.NewLister()
+			l.Options.Recursive = opts.Recursive
+			l.Options.Include = opts.Include
+			l.Options.Exclude = opts.Exclude
+			l.Options.Revision = opts.Revision
+			l.Options.Offline = opts.Offline
+
+			src, err := l.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("reading packages: %w", err)
+			}
+			defer src.Close()
+
+			nodelist, err := l.ReadPackages(context.Background(), src)
+			if err != nil {
+				return fmt.Errorf("reading packages: %w", err)
+			}
+			project := src.Project()
+
+			var f io.WriteCloser
+			if opts.File != "" {
+				f, err = os.Create(opts.File)
+				if err != nil {
+					return fmt.Errorf("opening file: %w", err)
+				}
+				defer f.Close()
+			} else {
+				f = os.Stdout
+			}
+
+			date, err := sourceDate(opts.Timestamp)
+			if err != nil {
+				return err
+			}
+			return sbom.WriteNodeList(sbom.FilterScope(nodelist, opts.Scope), project.Name, date, f, generatedSBOMFormat(opts.Format, opts.File))
+		},
+	}
+	opts.AddFlags(generateCmd)
+	parentCmd.AddCommand(generateCmd)
+}
+
+// generatedSBOMFormat returns the format to write a generated SBOM. When
+// it cannot be inferred from the options, SPDX is used.
+func generatedSBOMFormat(name, path string) protoformats.Format {
+	if format := sbomFormatFromOptions(name, path); format != "" {
+		return format
+	}
+	return sbomFormats["spdx"]
+}
+
+// emitSBOM writes the packages in the attested scope of the node list read
+// from the source tree as an SBOM to path, dated as the attestation. The
+// format is inferred from the file name.
+func emitSBOM(path string, nodelist *protobom.NodeList, name string, opts *attestOptions) error {
+	date, err := opts.date()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("opening SBOM file: %w", err)
+	}
+	defer f.Close()
+	return sbom.WriteNodeList(sbom.FilterScope(nodelist, opts.Scope), name, date, f, generatedSBOMFormat("", path))
+}
+
+// componentSBOMPath returns the path to emit the SBOM of a component when
+// splitting. The ID of the component is inserted before the extension of
+// path (eg deps.spdx.json becomes deps.component-web.spdx.json), the root
+// component is written to path.
+func componentSBOMPath(path, id string) string {
+	if id == "root" {
+		return path
+	}
+	for _, ext := range []string{".cdx.json", ".cyclonedx.json", ".spdx.json"} {
+		if strings.HasSuffix(path, ext) {
+			return strings.TrimSuffix(path, ext) + "." + id + ext
+		}
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + id + ext
+}
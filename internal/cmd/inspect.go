@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-attest/pkg/display"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+type inspectOptions struct {
+	Path   string
+	Format string
+	File   string
+}
+
+var inspectFormats = []string{"term", "csv", "markdown", "json"}
+
+// Validate checks the options in context with arguments
+func (ino *inspectOptions) Validate() error {
+	errs := []error{}
+	if ino.Path == "" {
+		errs = append(errs, errors.New("no attestation specified"))
+	}
+	if !slices.Contains(inspectFormats, ino.Format) {
+		errs = append(errs, fmt.Errorf("invalid format, must be one of %v", inspectFormats))
+	}
+	return errors.Join(errs...)
+}
+
+func (ino *inspectOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(
+		&ino.Format,
+		"format",
+		"f",
+		"term",
+		fmt.Sprintf("Output format, one of %v", inspectFormats),
+	)
+
+	cmd.PersistentFlags().StringVarP(
+		&ino.File,
+		"output",
+		"o",
+		"",
+		"write the report to file path (default STDOUT)",
+	)
+}
+
+func addInspect(parentCmd *cobra.Command) {
+	opts := inspectOptions{}
+	inspectCmd := &cobra.Command{
+		Short: "render the packages and metadata of an existing attestation",
+		Long: `render the packages and metadata of an existing attestation
+
+The attestation can be a sigstore bundle, a DSSE envelope, an in-toto
+statement or a bare Trusty predicate, the format is detected from its
+contents. Signatures are NOT verified, use the verify subcommand to check
+them before trusting the data.
+`,
+		Use:               "inspect [flags] bundle.json|envelope.json|statement.json|predicate.json",
+		Example:           fmt.Sprintf("%s inspect --format markdown attestation.json", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Path = args[0]
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			data, err := os.ReadFile(opts.Path)
+			if err != nil {
+				return fmt.Errorf("reading attestation: %w", err)
+			}
+			st, wrapper, err := trusty.Unwrap(data)
+			if err != nil {
+				return err
+			}
+			logrus.Infof("read Trusty predicate from %s", wrapper)
+			if wrapper == trusty.WrapperBundle || wrapper == trusty.WrapperEnvelope {
+				logrus.Warnf("the %s signatures were not verified, check them with %s verify", wrapper, appname)
+			}
+
+			var renderer display.PredicateRenderer
+			switch opts.Format {
+			case "term":
+				renderer = &display.TermRenderer{}
+			case "csv":
+				renderer = &display.CsvRenderer{}
+			case "markdown":
+				renderer = &display.MarkdownRenderer{}
+			case "json":
+				renderer = &display.JSONRenderer{}
+			}
+
+			var w io.Writer = os.Stdout
+			if opts.File != "" {
+				f, err := os.Create(opts.File)
+				if err != nil {
+					return fmt.Errorf("opening output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			return renderer.DisplayPredicate(w, st.Predicate)
+		},
+	}
+	opts.AddFlags(inspectCmd)
+	parentCmd.AddCommand(inspectCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+func addPredicate(parentCmd *cobra.Command) {
+	predicateCmd := &cobra.Command{
+		Short:             "work with the Trusty attestation predicate",
+		Use:               "predicate",
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+	}
+	addPredicateSchema(predicateCmd)
+	addPredicateValidate(predicateCmd)
+	parentCmd.AddCommand(predicateCmd)
+}
+
+func addPredicateSchema(parentCmd *cobra.Command) {
+	schemaCmd := &cobra.Command{
+		Short:             "print the JSON schema of the predicate",
+		Long:              fmt.Sprintf("print the JSON schema of the Trusty predicate (%s)", trusty.PredicateType),
+		Use:               "schema",
+		Example:           fmt.Sprintf("%s predicate schema > trusty.schema.json", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if _, err := os.Stdout.Write(trusty.PredicateSchema()); err != nil {
+				return fmt.Errorf("writing schema: %w", err)
+			}
+			return nil
+		},
+	}
+	parentCmd.AddCommand(schemaCmd)
+}
+
+func addPredicateValidate(parentCmd *cobra.Command) {
+	validateCmd := &cobra.Command{
+		Short:             "check a predicate or an in-toto statement carrying it against the JSON schema",
+		Use:               "validate predicate.json|statement.json",
+		Example:           fmt.Sprintf("%s predicate validate predicate.json", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no predicate specified")
+			}
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("reading predicate: %w", err)
+			}
+			if trusty.IsStatement(data) {
+				st, err := trusty.ParseStatement(data)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s is a valid %s statement with %d subjects\n", args[0], st.Type, len(st.Subjects))
+				return nil
+			}
+			if _, err := trusty.ParsePredicate(data); err != nil {
+				return err
+			}
+			fmt.Printf("%s is a valid %s predicate\n", args[0], trusty.PredicateType)
+			return nil
+		},
+	}
+	parentCmd.AddCommand(validateCmd)
+}
@@ -33,6 +33,11 @@ func init() {
 	)
 	addAttest(rootCmd)
 	addSBOM(rootCmd)
+	addDiff(rootCmd)
+	addPredicate(rootCmd)
+	addVerify(rootCmd)
+	addInspect(rootCmd)
+	addStore(rootCmd)
 	rootCmd.AddCommand(version.WithFont("doom"))
 }
 
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/stacklok/trusty-attest/pkg/display"
 	"github.com/stacklok/trusty-attest/pkg/sbom"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
 )
 
 type sbomOptions struct {
@@ -17,6 +19,7 @@ type sbomOptions struct {
 	File       string
 	Transients bool
 	Format     string
+	Scope      string
 }
 
 var formats = []string{"term", "csv"}
@@ -27,11 +30,14 @@ func (ao *sbomOptions) Validate() error {
 	if !slices.Contains(formats, ao.Format) && ao.Format != "" {
 		errs = append(errs, fmt.Errorf("invalid format, must be one of %v", formats))
 	}
-	return nil
+	if err := sbom.ValidateScopeFilter(ao.Scope); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
 func (o *sbomOptions) AddFlags(cmd *cobra.Command) {
-	cmd.PersistentFlags().BoolVarP(
+	cmd.Flags().BoolVarP(
 		&o.Transients,
 		"transients",
 		"t",
@@ -39,13 +45,20 @@ func (o *sbomOptions) AddFlags(cmd *cobra.Command) {
 		"include transient dependencies in report",
 	)
 
-	cmd.PersistentFlags().StringVarP(
+	cmd.Flags().StringVarP(
 		&o.Format,
 		"format",
 		"f",
 		"term",
 		fmt.Sprintf("Output format, one of %v", formats),
 	)
+
+	cmd.Flags().StringVar(
+		&o.Scope,
+		"scope",
+		sbom.ScopeAll,
+		fmt.Sprintf("dependency scopes to report, one of %v", sbom.ScopeFilters),
+	)
 }
 
 func addSBOM(parentCmd *cobra.Command) {
@@ -72,6 +85,7 @@ func addSBOM(parentCmd *cobra.Command) {
 			}
 
 			scorer := sbom.NewScorer()
+			scorer.Options.Scope = opts.Scope
 			results, err := scorer.ScoreSBOM(context.Background(), s)
 			if err != nil {
 				return fmt.Errorf("scoring nodelist: %w", err)
@@ -88,20 +102,21 @@ func addSBOM(parentCmd *cobra.Command) {
 				f = os.Stdout
 			}
 
-			// Choose the selected renderer
-			var renderer display.Renderer
+			// Choose the selected renderer, the terminal one heads the
+			// packages with their summary
 			switch opts.Format {
 			case "term":
-				renderer = &display.TermRenderer{}
+				pred := &trusty.Predicate{Packages: results, Summary: trusty.Summarize(results)}
+				return (&display.TermRenderer{}).DisplayPredicate(f, pred)
 			case "csv":
-				renderer = &display.CsvRenderer{}
+				return (&display.CsvRenderer{}).DisplayResultSet(f, results)
 			}
 
-			renderer.DisplayResultSet(f, results)
-
 			return nil
 		},
 	}
 	opts.AddFlags(createCmd)
+	addSBOMEnrich(createCmd)
+	addSBOMGenerate(createCmd)
 	parentCmd.AddCommand(createCmd)
 }
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-attest/pkg/store"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+type storeOptions struct {
+	Path string
+}
+
+// Validate checks the options in context with arguments
+func (so *storeOptions) Validate() error {
+	if so.Path == "" {
+		return errors.New("no store specified, set its path with --store")
+	}
+	return nil
+}
+
+func (so *storeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(
+		&so.Path,
+		"store",
+		"s",
+		"",
+		"path to the store, a JSON lines file or a directory",
+	)
+}
+
+type storeQueryOptions struct {
+	Digest  string
+	Project string
+	Since   string
+	Until   string
+}
+
+// Validate checks the options in context with arguments
+func (qo *storeQueryOptions) Validate() error {
+	errs := []error{}
+	for _, t := range []string{qo.Since, qo.Until} {
+		if t == "" {
+			continue
+		}
+		if _, err := trusty.ParseTimestamp(t); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (qo *storeQueryOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&qo.Digest,
+		"digest",
+		"",
+		"subject digest, as algorithm:value or the bare value",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&qo.Project,
+		"project",
+		"",
+		"name of the attested package",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&qo.Since,
+		"since",
+		"",
+		"only attestations dated at or after this time (seconds since the epoch or RFC 3339)",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&qo.Until,
+		"until",
+		"",
+		"only attestations dated at or before this time (seconds since the epoch or RFC 3339)",
+	)
+}
+
+// query returns the store query of the options
+func (qo *storeQueryOptions) query() (*store.Query, error) {
+	q := &store.Query{Digest: qo.Digest, Project: qo.Project}
+	if qo.Since != "" {
+		t, err := trusty.ParseTimestamp(qo.Since)
+		if err != nil {
+			return nil, err
+		}
+		q.Since = t
+	}
+	if qo.Until != "" {
+		t, err := trusty.ParseTimestamp(qo.Until)
+		if err != nil {
+			return nil, err
+		}
+		q.Until = t
+	}
+	return q, nil
+}
+
+func addStore(parentCmd *cobra.Command) {
+	opts := storeOptions{}
+	storeCmd := &cobra.Command{
+		Short: "read the attestations kept in a local store",
+		Long: `read the attestations kept in a local store
+
+Attestations are appended to the store with attest --store. The store is an
+append-only JSON lines file where each entry holds an attestation document
+indexed by the digests of its subjects and its date.
+`,
+		Use:               "store",
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+	}
+	opts.AddFlags(storeCmd)
+	addStoreList(storeCmd, &opts)
+	addStoreGet(storeCmd, &opts)
+	addStoreQuery(storeCmd, &opts)
+	parentCmd.AddCommand(storeCmd)
+}
+
+func addStoreList(parentCmd *cobra.Command, opts *storeOptions) {
+	listCmd := &cobra.Command{
+		Short:             "list the attestations in the store",
+		Use:               "list",
+		Example:           fmt.Sprintf("%s store list --store attestations/", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			entries, err := store.NewLocal(opts.Path).List()
+			if err != nil {
+				return err
+			}
+			return writeEntries(os.Stdout, entries)
+		},
+	}
+	parentCmd.AddCommand(listCmd)
+}
+
+func addStoreQuery(parentCmd *cobra.Command, opts *storeOptions) {
+	qopts := storeQueryOptions{}
+	queryCmd := &cobra.Command{
+		Short:             "list the attestations about a subject, project or time range",
+		Use:               "query [flags]",
+		Example:           fmt.Sprintf("%s store query --store attestations/ --digest sha256:2cf24d... --since 2024-06-01T00:00:00Z", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := errors.Join(opts.Validate(), qopts.Validate()); err != nil {
+				return err
+			}
+			q, err := qopts.query()
+			if err != nil {
+				return err
+			}
+			entries, err := store.NewLocal(opts.Path).Query(q)
+			if err != nil {
+				return err
+			}
+			return writeEntries(os.Stdout, entries)
+		},
+	}
+	qopts.AddFlags(queryCmd)
+	parentCmd.AddCommand(queryCmd)
+}
+
+func addStoreGet(parentCmd *cobra.Command, opts *storeOptions) {
+	var file string
+	getCmd := &cobra.Command{
+		Short:             "write an attestation from the store, selected by its id or an id prefix",
+		Use:               "get [flags] id",
+		Example:           fmt.Sprintf("%s store get --store attestations/ 3f2a9c > attestation.json", appname),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				return errors.New("no attestation id specified")
+			}
+			entry, err := store.NewLocal(opts.Path).Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = os.Stdout
+			if file != "" {
+				f, err := os.Create(file)
+				if err != nil {
+					return fmt.Errorf("opening output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			data, err := json.MarshalIndent(entry.Document, "", "  ")
+			if err != nil {
+				return fmt.Errorf("formatting attestation: %w", err)
+			}
+			if _, err := w.Write(append(data, '\n')); err != nil {
+				return fmt.Errorf("writing attestation: %w", err)
+			}
+			return nil
+		},
+	}
+	getCmd.PersistentFlags().StringVarP(
+		&file,
+		"output",
+		"o",
+		"",
+		"write the attestation to file path (default STDOUT)",
+	)
+	parentCmd.AddCommand(getCmd)
+}
+
+// writeEntries prints a table of store entries
+func writeEntries(w io.Writer, entries []store.Entry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tPROJECT\tFORMAT\tSUBJECTS")
+	for i := range entries {
+		e := &entries[i]
+		project := e.Project
+		if e.Version != "" {
+			project += "@" + e.Version
+		}
+		subjects := []string{}
+		for _, s := range e.Subjects {
+			subjects = append(subjects, s.Name)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			e.ID[:min(12, len(e.ID))], e.Date.Format(time.RFC3339), project, e.Format, strings.Join(subjects, ", "),
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("writing entries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stacklok/trusty-attest/pkg/policy"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+	"github.com/stacklok/trusty-attest/pkg/verify"
+)
+
+type verifyOptions struct {
+	verify.Options
+	BundlePath string
+	Subjects   []string
+	Digests    []string
+	Policy     string
+	File       string
+	VSA        string
+	Bundle     bool
+}
+
+// Validate checks the options in context with arguments
+func (vo *verifyOptions) Validate() error {
+	errs := []error{}
+	if vo.BundlePath == "" {
+		errs = append(errs, errors.New("no bundle or envelope specified"))
+	}
+	if err := vo.Options.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, d := range vo.Digests {
+		if _, err := trusty.ParseSubjectDigest(d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if vo.VSA != "" && vo.Policy == "" {
+		errs = append(errs, errors.New("--vsa requires a --policy to evaluate"))
+	}
+	if vo.Bundle && vo.VSA == "" {
+		errs = append(errs, errors.New("--bundle signs the verification summary, it requires --vsa"))
+	}
+	return errors.Join(errs...)
+}
+
+func (vo *verifyOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&vo.PublicKey,
+		"key",
+		"",
+		"path to the PEM public key of a key signed envelope or bundle",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&vo.TrustedRoot,
+		"trusted-root",
+		"",
+		"path to the sigstore trusted root JSON file",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&vo.CertificateIdentity,
+		"certificate-identity",
+		"",
+		"expected identity of the signer (eg email or workflow URI)",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&vo.CertificateIdentityRegexp,
+		"certificate-identity-regexp",
+		"",
+		"regular expression matching the identity of the signer",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&vo.CertificateOIDCIssuer,
+		"certificate-oidc-issuer",
+		"",
+		"expected OIDC issuer of the signer identity",
+	)
+
+	cmd.PersistentFlags().BoolVar(
+		&vo.LegacyBundle,
+		"legacy-bundle",
+		false,
+		"read a bundle written by an older trusty attest --bundle (PEM certificates, hex log hashes)",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&vo.Subjects,
+		"subject",
+		[]string{},
+		"local file that must match one of the attestation subjects",
+	)
+
+	cmd.PersistentFlags().StringSliceVar(
+		&vo.Digests,
+		"subject-digest",
+		[]string{},
+		"digest that must match one of the attestation subjects, as name=algorithm:digest",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&vo.Policy,
+		"policy",
+		"",
+		"policy file to evaluate the predicate against",
+	)
+
+	cmd.PersistentFlags().StringVarP(
+		&vo.File,
+		"output",
+		"o",
+		"",
+		"write the verified predicate to file path (default STDOUT)",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&vo.VSA,
+		"vsa",
+		"",
+		"write a SLSA verification summary attestation of the policy result to file path",
+	)
+
+	cmd.PersistentFlags().BoolVarP(
+		&vo.Bundle,
+		"bundle",
+		"b",
+		false,
+		"sign the verification summary in a sigstore bundle (runs the oauth flow)",
+	)
+}
+
+func addVerify(parentCmd *cobra.Command) {
+	opts := verifyOptions{}
+	verifyCmd := &cobra.Command{
+		Short: "verify a signed attestation bundle and extract its predicate",
+		Long: `verify a signed attestation bundle and extract its predicate
+
+The bundle signature, certificate and transparency log inclusion are checked
+against a local trusted root, no network access is required. Attestations
+signed with a local key (a DSSE envelope or a bundle) are verified with the
+public key set in --key instead. When artifacts are passed with --subject or
+--subject-digest, each must match a subject of the attestation. The predicate
+is then written out and, if a policy is specified, evaluated against it.
+
+With --vsa, the policy result is recorded in a SLSA verification summary
+attestation (VSA) about the attestation subjects. It references the policy
+and the verified attestation by digest and can be signed with --bundle.
+`,
+		Use: "verify [flags] bundle.json|envelope.json",
+		Example: fmt.Sprintf(
+			"%s verify --trusted-root trusted_root.json --certificate-identity me@example.com --certificate-oidc-issuer https://accounts.google.com --subject myapp.tar.gz bundle.json\n%s verify --key trusty.pub envelope.json\n%s verify --key trusty.pub --policy policy.yaml --vsa vsa.json envelope.json",
+			appname, appname, appname,
+		),
+		SilenceUsage:      false,
+		SilenceErrors:     true,
+		PersistentPreRunE: initLogging,
+		RunE: func(_ *cobra.Command, args []string) error {
+			ctx := context.Background()
+			if len(args) > 0 {
+				opts.BundlePath = args[0]
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+
+			var pol *policy.Policy
+			if opts.Policy != "" {
+				p, err := policy.Load(opts.Policy)
+				if err != nil {
+					return err
+				}
+				pol = p
+			}
+
+			artifacts, err := opts.artifacts()
+			if err != nil {
+				return err
+			}
+
+			res, err := verify.Attestation(opts.BundlePath, &opts.Options)
+			if err != nil {
+				return err
+			}
+			logrus.Infof("attestation signature verified, signed by %s", signerIdentity(res))
+
+			if err := trusty.MatchSubjects(res.Statement.Subjects, artifacts); err != nil {
+				return fmt.Errorf("checking subjects: %w", err)
+			}
+			if len(artifacts) > 0 {
+				logrus.Infof("%d artifacts match the attestation subjects", len(artifacts))
+			}
+
+			if err := writeVerifiedPredicate(opts.File, res.Statement.Predicate); err != nil {
+				return err
+			}
+
+			if pol == nil {
+				return nil
+			}
+			result := pol.Evaluate(res.Statement.Predicate)
+			for _, v := range result.Violations {
+				logrus.Errorf("policy violation (%s): %s@%s: %s", v.Rule, v.Package, v.Version, v.Message)
+			}
+			if opts.VSA != "" {
+				if err := writeVSA(ctx, &opts, res.Statement.Subjects, artifacts, result.Passed); err != nil {
+					return err
+				}
+				logrus.Infof("verification summary written to %s", opts.VSA)
+			}
+			if !result.Passed {
+				return fmt.Errorf("predicate failed the policy with %d violations", len(result.Violations))
+			}
+			logrus.Info("predicate passed the policy")
+			return nil
+		},
+	}
+	opts.AddFlags(verifyCmd)
+	parentCmd.AddCommand(verifyCmd)
+}
+
+// artifacts returns the resource descriptors of the local artifacts that
+// must match the attestation subjects
+func (vo *verifyOptions) artifacts() ([]*intotov1.ResourceDescriptor, error) {
+	artifacts := []*intotov1.ResourceDescriptor{}
+	for _, path := range vo.Subjects {
+		s, err := trusty.FileSubject(path)
+		if err != nil {
+			return nil, fmt.Errorf("computing subject: %w", err)
+		}
+		artifacts = append(artifacts, s)
+	}
+	for _, d := range vo.Digests {
+		s, err := trusty.ParseSubjectDigest(d)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, s)
+	}
+	return artifacts, nil
+}
+
+// signerIdentity returns the SAN of the verified signing certificate or
+// the id of the verifying key
+func signerIdentity(res *verify.Result) string {
+	if res.KeyID != "" {
+		return fmt.Sprintf("key %s", res.KeyID)
+	}
+	if res.Verification == nil || res.Verification.Signature == nil || res.Verification.Signature.Certificate == nil {
+		return "unknown identity"
+	}
+	cert := res.Verification.Signature.Certificate
+	return fmt.Sprintf("%s (%s)", cert.SubjectAlternativeName.Value, cert.Issuer)
+}
+
+func writeVerifiedPredicate(path string, pred *trusty.Predicate) error {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("opening predicate file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	data, err := trusty.CanonicalJSON(pred)
+	if err != nil {
+		return fmt.Errorf("encoding predicate: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing predicate: %w", err)
+	}
+	return nil
+}
+
+// writeVSA writes the verification summary attestation of the policy result
+// about the verified artifact: the only subject of the attestation or the
+// one matching the artifact passed with the subject flags. The summary
+// references the policy and the attestation by their digests.
+func writeVSA(ctx context.Context, opts *verifyOptions, subjects, artifacts []*intotov1.ResourceDescriptor, passed bool) error {
+	subject, err := trusty.VSASubject(subjects, artifacts)
+	if err != nil {
+		return fmt.Errorf("choosing the verification summary subject: %w", err)
+	}
+	policyRef, err := fileRef(opts.Policy)
+	if err != nil {
+		return err
+	}
+	inputRef, err := fileRef(opts.BundlePath)
+	if err != nil {
+		return err
+	}
+
+	verified := time.Now().UTC()
+	if t, err := trusty.SourceDateEpoch(); err != nil {
+		return err
+	} else if t != nil {
+		verified = *t
+	}
+
+	vsa := trusty.NewVSA(subject, policyRef, []trusty.ResourceRef{inputRef}, passed, verified)
+	statement, err := trusty.AttestVSA(subject, vsa)
+	if err != nil {
+		return fmt.Errorf("creating verification summary: %w", err)
+	}
+	data, err := trusty.CanonicalJSON(statement)
+	if err != nil {
+		return fmt.Errorf("encoding verification summary: %w", err)
+	}
+
+	f, err := os.Create(opts.VSA)
+	if err != nil {
+		return fmt.Errorf("opening verification summary file: %w", err)
+	}
+	defer f.Close()
+	return writeStatement(ctx, f, data, opts.Bundle)
+}
+
+// fileRef returns a reference to a local file by path and digests
+func fileRef(path string) (trusty.ResourceRef, error) {
+	s, err := trusty.FileSubject(path)
+	if err != nil {
+		return trusty.ResourceRef{}, fmt.Errorf("computing digest of %s: %w", path, err)
+	}
+	return trusty.ResourceRef{URI: path, Digest: s.GetDigest()}, nil
+}
@@ -0,0 +1,142 @@
+package packages
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"sigs.k8s.io/release-utils/util"
+)
+
+// Component is a directory in the scanned tree with manifests of one or
+// more ecosystems. Each component is cataloged as a unit.
+type Component struct {
+	// Path is the directory of the component relative to the scanned
+	// directory, "." is the top of the tree.
+	Path       string
+	Ecosystems []Ecosystem
+}
+
+// ID returns the node ID of the component root
+func (c *Component) ID() string {
+	if c.Path == "." {
+		return "root"
+	}
+	return "component-" + nonIDChars.ReplaceAllString(c.Path, "-")
+}
+
+var nonIDChars = regexp.MustCompile(`[^a-zA-Z0-9.]+`)
+
+// FindComponents returns the components found in path. If the lister is
+// not set to recurse, only the top of the directory is inspected.
+func (l *Lister) FindComponents(dir string) ([]Component, error) {
+	if !util.Exists(dir) {
+		return nil, fmt.Errorf("specified directory doesn't exist")
+	}
+
+	components := []Component{}
+	if !l.Options.Recursive {
+		ecosystems, err := l.GuessEcosystems(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(ecosystems) > 0 {
+			components = append(components, Component{Path: ".", Ecosystems: ecosystems})
+		}
+		return components, nil
+	}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return fmt.Errorf("computing relative path: %w", err)
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel != "." && (d.Name() == ".git" || l.excluded(rel)) {
+			return filepath.SkipDir
+		}
+
+		if !l.included(rel) {
+			return nil
+		}
+
+		ecosystems, err := l.GuessEcosystems(p)
+		if err != nil {
+			return err
+		}
+		if len(ecosystems) > 0 {
+			components = append(components, Component{Path: rel, Ecosystems: ecosystems})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking directory: %w", err)
+	}
+
+	return components, nil
+}
+
+// excluded returns true if a relative path matches the exclude globs
+func (l *Lister) excluded(rel string) bool {
+	for _, pattern := range l.Options.Exclude {
+		if m, err := doublestar.Match(normalizeGlob(pattern), rel); err == nil && m {
+			return true
+		}
+	}
+	return false
+}
+
+// included returns true if a relative path matches any of the include
+// globs. When no globs are defined, all paths are included.
+func (l *Lister) included(rel string) bool {
+	if len(l.Options.Include) == 0 {
+		return true
+	}
+	for _, pattern := range l.Options.Include {
+		if m, err := doublestar.Match(normalizeGlob(pattern), rel); err == nil && m {
+			return true
+		}
+	}
+	return false
+}
+
+// resolverExclusions translates the exclude globs to syft exclusions
+func (l *Lister) resolverExclusions() []string {
+	ret := []string{}
+	for _, pattern := range l.Options.Exclude {
+		pattern = normalizeGlob(pattern)
+		if !strings.HasPrefix(pattern, "**/") {
+			pattern = "./" + pattern
+		}
+		ret = append(ret, pattern)
+	}
+	return ret
+}
+
+// normalizeGlob turns bare names (eg "vendor") into patterns that match
+// at any depth in the tree.
+func normalizeGlob(pattern string) string {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	if !strings.Contains(pattern, "/") {
+		return "**/" + pattern
+	}
+	return pattern
+}
+
+// manifestDir returns the directory of the file where a package was
+// found, relative to the root of the scanned directory.
+func manifestDir(realPath string) string {
+	return path.Dir(strings.TrimPrefix(realPath, "/"))
+}
@@ -0,0 +1,110 @@
+package packages
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeGlob(t *testing.T) {
+	for pattern, expected := range map[string]string{
+		"vendor":          "**/vendor",
+		"vendor/":         "**/vendor",
+		"./vendor":        "**/vendor",
+		"services/*":      "services/*",
+		"./services/api/": "services/api",
+		"**/testdata":     "**/testdata",
+	} {
+		if got := normalizeGlob(pattern); got != expected {
+			t.Errorf("normalizeGlob(%q): expected %q, got %q", pattern, expected, got)
+		}
+	}
+}
+
+// The fixture tree has a Go module at the top, an npm project in web with
+// an installed dependency in node_modules, a Go and Python service with a
+// vendored module, a Go tool and a docs directory without manifests.
+func TestFindComponents(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		opts     Options
+		expected []Component
+	}{
+		{
+			name:     "top-only",
+			opts:     Options{Exclude: DefaultExclude},
+			expected: []Component{{".", []Ecosystem{Go}}},
+		},
+		{
+			name: "recursive",
+			opts: Options{Recursive: true, Exclude: DefaultExclude},
+			expected: []Component{
+				{".", []Ecosystem{Go}},
+				{"services/api", []Ecosystem{Python, Go}},
+				{"tools/gen", []Ecosystem{Go}},
+				{"web", []Ecosystem{Npm}},
+			},
+		},
+		{
+			name: "no-excludes",
+			opts: Options{Recursive: true},
+			expected: []Component{
+				{".", []Ecosystem{Go}},
+				{"services/api", []Ecosystem{Python, Go}},
+				{"services/api/vendor/example.com/x", []Ecosystem{Go}},
+				{"tools/gen", []Ecosystem{Go}},
+				{"web", []Ecosystem{Npm}},
+				{"web/node_modules/dep", []Ecosystem{Npm}},
+			},
+		},
+		{
+			// Excluded directories are pruned, the components below them
+			// are not found even when they match the includes
+			name: "pruned",
+			opts: Options{Recursive: true, Include: []string{"**"}, Exclude: []string{"services", "node_modules"}},
+			expected: []Component{
+				{".", []Ecosystem{Go}},
+				{"tools/gen", []Ecosystem{Go}},
+				{"web", []Ecosystem{Npm}},
+			},
+		},
+		{
+			name: "include",
+			opts: Options{Recursive: true, Include: []string{"services/**", "web"}, Exclude: DefaultExclude},
+			expected: []Component{
+				{"services/api", []Ecosystem{Python, Go}},
+				{"web", []Ecosystem{Npm}},
+			},
+		},
+		{
+			name: "bare-name-include",
+			opts: Options{Recursive: true, Include: []string{"gen"}},
+			expected: []Component{
+				{"tools/gen", []Ecosystem{Go}},
+			},
+		},
+		{
+			name: "path-exclude",
+			opts: Options{Recursive: true, Exclude: []string{"services/api", "**/node_modules/**"}},
+			expected: []Component{
+				{".", []Ecosystem{Go}},
+				{"tools/gen", []Ecosystem{Go}},
+				{"web", []Ecosystem{Npm}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &Lister{Options: tc.opts}
+			components, err := l.FindComponents("testdata/components")
+			if err != nil {
+				t.Fatalf("finding components: %v", err)
+			}
+			if !reflect.DeepEqual(components, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, components)
+			}
+		})
+	}
+
+	if _, err := NewLister().FindComponents("testdata/missing"); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
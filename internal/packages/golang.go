@@ -0,0 +1,460 @@
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anchore/packageurl-go"
+	"github.com/anchore/syft/syft/artifact"
+	"github.com/anchore/syft/syft/file"
+	"github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/pkg/cataloger/generic"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// golangCatalogers returns the catalogers that read the modules required
+// in go.mod files.
+func golangCatalogers(opts *Options) []pkg.Cataloger {
+	return []pkg.Cataloger{
+		generic.NewCataloger("go-module-graph-cataloger").
+			WithParserByGlobs(parseGoMod(opts.Offline), "**/go.mod"),
+	}
+}
+
+// parseGoMod reads the modules required in a go.mod file. Requirements not
+// marked as indirect are the direct dependencies, those only imported from
+// test files are development dependencies. The relationships among the
+// modules are read from their go.mod files, taken from the local module
+// cache or the module proxy and checked against the hashes in go.sum so
+// that they don't depend on the machine running the scan. Modules whose
+// go.mod can't be obtained are marked as having unknown dependencies. When
+// offline, the proxy is not used.
+func parseGoMod(offline bool) generic.Parser {
+	return func(ctx context.Context, resolver file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+		return readGoMod(ctx, resolver, reader, offline)
+	}
+}
+
+// readGoMod parses the go.mod file read by reader, see parseGoMod
+func readGoMod(ctx context.Context, resolver file.Resolver, reader file.LocationReadCloser, offline bool) ([]pkg.Package, []artifact.Relationship, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading go.mod: %w", err)
+	}
+
+	// The replace directives are only read in strict mode. Fall back to
+	// lax parsing for directives unknown to this version of x/mod.
+	mod, err := modfile.Parse(reader.RealPath, data, nil)
+	if err != nil {
+		logrus.Debugf("parsing %s in lax mode: %v", reader.RealPath, err)
+		mod, err = modfile.ParseLax(reader.RealPath, data, nil)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing go.mod: %w", err)
+	}
+
+	// Index the replace directives, they apply to a specific version
+	// or to all versions of a module.
+	replaces := map[string]module.Version{}
+	for _, r := range mod.Replace {
+		replaces[r.Old.Path+"@"+r.Old.Version] = r.New
+	}
+	replaced := func(m module.Version) module.Version {
+		if r, ok := replaces[m.Path+"@"+m.Version]; ok {
+			return r
+		}
+		if r, ok := replaces[m.Path+"@"]; ok {
+			return r
+		}
+		return m
+	}
+
+	required := []string{}
+	for _, r := range mod.Require {
+		required = append(required, r.Mod.Path)
+	}
+	importScopes := goImportScopes(resolver, path.Dir(reader.RealPath), required)
+
+	fetcher := newGoModFetcher(readGoSum(resolver, path.Join(path.Dir(reader.RealPath), "go.sum")), offline)
+	mods := []module.Version{}
+	for _, r := range mod.Require {
+		mods = append(mods, replaced(r.Mod))
+	}
+	fetched := fetcher.requiresAll(ctx, mods)
+
+	pkgs := []pkg.Package{}
+	index := map[string]pkg.Package{}
+	requires := map[string][]string{}
+	unknown := []string{}
+	for i, r := range mod.Require {
+		m := mods[i]
+		// Modules replaced by a directory live in the tree
+		if m.Version == "" {
+			continue
+		}
+		meta := dependencyMetadata{Direct: !r.Indirect}
+		if scope, ok := importScopes[r.Mod.Path]; ok && meta.Direct {
+			meta.Scope = scope
+		}
+		if err := fetched[i].err; err != nil {
+			logrus.Debugf("unable to read dependencies of %s: %v", r.Mod.Path, err)
+			meta.EdgesUnknown = true
+			unknown = append(unknown, r.Mod.Path)
+		}
+		p := newGoPackage(m.Path, m.Version, meta, reader.Location)
+		index[r.Mod.Path] = p
+		requires[r.Mod.Path] = fetched[i].requires
+		pkgs = append(pkgs, p)
+	}
+	if len(unknown) > 0 {
+		logrus.Warnf(
+			"%s: the dependencies of %d modules are unknown, their go.mod is not in the module cache or proxy or does not match go.sum: %s",
+			reader.RealPath, len(unknown), strings.Join(unknown, ", "),
+		)
+	}
+
+	rels := []artifact.Relationship{}
+	for _, r := range mod.Require {
+		parent, ok := index[r.Mod.Path]
+		if !ok {
+			continue
+		}
+		// Requirements point to the version selected for the build
+		for _, req := range requires[r.Mod.Path] {
+			if dep, ok := index[req]; ok && req != r.Mod.Path {
+				rels = append(rels, dependencyOf(dep, parent, trusty.ScopeRuntime))
+			}
+		}
+	}
+	return pkgs, rels, nil
+}
+
+// goImportScopes inspects the imports in the source code of the module in
+// modDir and returns the scope in which each required module is imported:
+// runtime if any non test file imports it, dev if only tests do. Modules
+// not imported are not in the map. Files of nested modules are skipped.
+func goImportScopes(resolver file.Resolver, modDir string, required []string) map[string]trusty.Scope {
+	scopes := map[string]trusty.Scope{}
+
+	// Index the directories of the modules in the tree
+	modDirs := map[string]struct{}{}
+	if locations, err := resolver.FilesByGlob("**/go.mod"); err == nil {
+		for _, l := range locations {
+			modDirs[path.Dir(l.RealPath)] = struct{}{}
+		}
+	}
+
+	locations, err := resolver.FilesByGlob(path.Join(modDir, "**/*.go"))
+	if err != nil {
+		logrus.Debugf("listing go files in %s: %v", modDir, err)
+		return scopes
+	}
+
+	fset := token.NewFileSet()
+	for _, l := range locations {
+		if goModuleDir(modDirs, l.RealPath) != modDir {
+			continue
+		}
+		r, err := resolver.FileContentsByLocation(l)
+		if err != nil {
+			continue
+		}
+		f, err := parser.ParseFile(fset, l.RealPath, r, parser.ImportsOnly)
+		r.Close()
+		if err != nil {
+			logrus.Debugf("parsing imports of %s: %v", l.RealPath, err)
+			continue
+		}
+
+		scope := trusty.ScopeRuntime
+		if strings.HasSuffix(l.RealPath, "_test.go") {
+			scope = trusty.ScopeDev
+		}
+		for _, imp := range f.Imports {
+			if m := importModule(strings.Trim(imp.Path.Value, `"`), required); m != "" {
+				addScope(scopes, m, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// goModuleDir returns the directory of the module that owns a go file:
+// the deepest directory with a go.mod above it.
+func goModuleDir(modDirs map[string]struct{}, goFile string) string {
+	for dir := path.Dir(goFile); ; dir = path.Dir(dir) {
+		if _, ok := modDirs[dir]; ok {
+			return dir
+		}
+		if dir == "/" || dir == "." {
+			return ""
+		}
+	}
+}
+
+// importModule returns the module providing an import path, the longest
+// module path that is a prefix of it.
+func importModule(importPath string, modules []string) string {
+	ret := ""
+	for _, m := range modules {
+		if (importPath == m || strings.HasPrefix(importPath, m+"/")) && len(m) > len(ret) {
+			ret = m
+		}
+	}
+	return ret
+}
+
+// readGoSum returns the hashes recorded in a go.sum file keyed by module
+// path and version (eg "golang.org/x/mod v0.17.0/go.mod"). If the file
+// cannot be read, it returns nothing.
+func readGoSum(resolver file.Resolver, p string) map[string]string {
+	sums := map[string]string{}
+	locations, err := resolver.FilesByPath(p)
+	if err != nil || len(locations) == 0 {
+		return sums
+	}
+	r, err := resolver.FileContentsByLocation(locations[0])
+	if err != nil {
+		return sums
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		sums[fields[0]+" "+fields[1]] = fields[2]
+	}
+	return sums
+}
+
+// goModFetcher reads the go.mod files of module versions from the local
+// module cache, falling back to the module proxy, and checks them against
+// the go.sum hashes of the project.
+type goModFetcher struct {
+	cache   string
+	proxies []string
+	noProxy string
+	sums    map[string]string
+	client  *http.Client
+}
+
+// goModFetchWorkers bounds the go.mod files read at the same time and
+// goModFetchTimeout the time spent reading those required by a go.mod file.
+// The modules not read by then are left with unknown dependencies.
+const (
+	goModFetchWorkers = 8
+	goModFetchTimeout = 2 * time.Minute
+)
+
+func newGoModFetcher(sums map[string]string, offline bool) *goModFetcher {
+	noProxy := os.Getenv("GONOPROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("GOPRIVATE")
+	}
+	proxies := goProxies()
+	if offline {
+		proxies = nil
+	}
+	return &goModFetcher{
+		cache:   goModCache(),
+		proxies: proxies,
+		noProxy: noProxy,
+		sums:    sums,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// goModRequires are the modules required by a module version, or the
+// error reading its go.mod file
+type goModRequires struct {
+	requires []string
+	err      error
+}
+
+// requiresAll reads the requirements of the module versions concurrently.
+// Modules without version (replaced by directories) are skipped.
+func (f *goModFetcher) requiresAll(ctx context.Context, mods []module.Version) []goModRequires {
+	ctx, cancel := context.WithTimeout(ctx, goModFetchTimeout)
+	defer cancel()
+
+	ret := make([]goModRequires, len(mods))
+	sem := make(chan struct{}, goModFetchWorkers)
+	var wg sync.WaitGroup
+	for i, m := range mods {
+		if m.Version == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			reqs, err := f.requires(ctx, m)
+			ret[i] = goModRequires{requires: reqs, err: err}
+		}()
+	}
+	wg.Wait()
+	return ret
+}
+
+// requires returns the modules required by the go.mod file of a module
+// version. The file must match the hash recorded in go.sum.
+func (f *goModFetcher) requires(ctx context.Context, m module.Version) ([]string, error) {
+	sum, ok := f.sums[m.Path+" "+m.Version+"/go.mod"]
+	if !ok {
+		return nil, fmt.Errorf("%s@%s/go.mod is not in go.sum", m.Path, m.Version)
+	}
+
+	data, err := f.read(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hashing go.mod: %w", err)
+	}
+	if h != sum {
+		return nil, fmt.Errorf("%s@%s/go.mod does not match go.sum", m.Path, m.Version)
+	}
+
+	mod, err := modfile.ParseLax(m.Path+"@"+m.Version+"/go.mod", data, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, r := range mod.Require {
+		ret = append(ret, r.Mod.Path)
+	}
+	return ret, nil
+}
+
+// read returns the contents of the go.mod file of a module version
+func (f *goModFetcher) read(ctx context.Context, m module.Version) ([]byte, error) {
+	escapedPath, err := module.EscapePath(m.Path)
+	if err != nil {
+		return nil, err
+	}
+	escapedVersion, err := module.EscapeVersion(m.Version)
+	if err != nil {
+		return nil, err
+	}
+	modFile := escapedPath + "/@v/" + escapedVersion + ".mod"
+
+	if f.cache != "" {
+		data, err := os.ReadFile(filepath.Join(f.cache, "cache", "download", filepath.FromSlash(modFile)))
+		if err == nil {
+			return data, nil
+		}
+	}
+
+	if len(f.proxies) == 0 || module.MatchPrefixPatterns(f.noProxy, m.Path) {
+		return nil, fmt.Errorf("%s@%s is not in the module cache", m.Path, m.Version)
+	}
+	errs := []error{}
+	for _, proxy := range f.proxies {
+		data, err := f.download(ctx, strings.TrimSuffix(proxy, "/")+"/"+modFile)
+		if err == nil {
+			return data, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func (f *goModFetcher) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// goProxies returns the module proxy URLs listed in GOPROXY up to the
+// first "direct" or "off" entry. Modules are not fetched from their
+// repositories.
+func goProxies() []string {
+	env := os.Getenv("GOPROXY")
+	if env == "" {
+		env = "https://proxy.golang.org,direct"
+	}
+	ret := []string{}
+	for _, proxy := range strings.FieldsFunc(env, func(r rune) bool { return r == ',' || r == '|' }) {
+		if proxy == "direct" || proxy == "off" {
+			break
+		}
+		ret = append(ret, proxy)
+	}
+	return ret
+}
+
+// goModCache returns the location of the Go module cache following the
+// same rules as the go command.
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// newGoPackage returns a go module found at location
+func newGoPackage(modPath, version string, meta dependencyMetadata, location file.Location) pkg.Package {
+	namespace := ""
+	name := modPath
+	if i := strings.LastIndex(modPath, "/"); i != -1 {
+		namespace, name = modPath[:i], modPath[i+1:]
+	}
+	p := pkg.Package{
+		Name:      modPath,
+		Version:   version,
+		Locations: file.NewLocationSet(location),
+		PURL: packageurl.NewPackageURL(
+			packageurl.TypeGolang, namespace, name, version, nil, "",
+		).ToString(),
+		Language: pkg.Go,
+		Type:     pkg.GoModulePkg,
+		Metadata: meta,
+	}
+	p.SetID()
+	return p
+}
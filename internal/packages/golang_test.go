@@ -0,0 +1,144 @@
+package packages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// The fixture module requires a, imported by the code, and t, only imported
+// by the tests. Their dependencies are read from the go.mod files in the
+// fixture module cache and proxy: a and t require b, a also requires
+// proxied, which is only available from the proxy. missing can't be found
+// and the cached go.mod of tampered doesn't match its go.sum hash.
+func TestParseGoMod(t *testing.T) {
+	cache, err := filepath.Abs("testdata/golang/modcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(http.FileServer(http.Dir("testdata/golang/proxy")))
+	defer proxy.Close()
+
+	packages := func(proxied bool) map[string]parsedPackage {
+		return map[string]parsedPackage{
+			"example.com/a@v1.0.0":        {Direct: true, Scope: trusty.ScopeRuntime},
+			"example.com/t@v1.0.0":        {Direct: true, Scope: trusty.ScopeDev},
+			"example.com/b@v1.1.0":        {Scope: trusty.ScopeRuntime},
+			"example.com/missing@v1.0.0":  {Scope: trusty.ScopeRuntime, EdgesUnknown: true},
+			"example.com/proxied@v1.0.0":  {Scope: trusty.ScopeRuntime, EdgesUnknown: !proxied},
+			"example.com/tampered@v1.0.0": {Scope: trusty.ScopeRuntime, EdgesUnknown: true},
+		}
+	}
+	edges := []string{
+		"example.com/a@v1.0.0 -> example.com/b@v1.1.0 (runtime)",
+		"example.com/a@v1.0.0 -> example.com/proxied@v1.0.0 (runtime)",
+		"example.com/t@v1.0.0 -> example.com/b@v1.1.0 (runtime)",
+	}
+
+	for _, tc := range []struct {
+		name     string
+		goproxy  string
+		noproxy  string
+		offline  bool
+		packages map[string]parsedPackage
+		edges    []string
+	}{
+		{
+			name:     "cache",
+			goproxy:  "off",
+			packages: packages(false),
+			edges:    edges,
+		},
+		{
+			name:     "proxy",
+			goproxy:  proxy.URL + ",direct",
+			packages: packages(true),
+			edges: []string{
+				"example.com/a@v1.0.0 -> example.com/b@v1.1.0 (runtime)",
+				"example.com/a@v1.0.0 -> example.com/proxied@v1.0.0 (runtime)",
+				"example.com/proxied@v1.0.0 -> example.com/b@v1.1.0 (runtime)",
+				"example.com/t@v1.0.0 -> example.com/b@v1.1.0 (runtime)",
+			},
+		},
+		{
+			name:     "private",
+			goproxy:  proxy.URL,
+			noproxy:  "example.com/proxied",
+			packages: packages(false),
+			edges:    edges,
+		},
+		{
+			name:     "offline",
+			goproxy:  proxy.URL,
+			offline:  true,
+			packages: packages(false),
+			edges:    edges,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GOMODCACHE", cache)
+			t.Setenv("GOPROXY", tc.goproxy)
+			t.Setenv("GONOPROXY", tc.noproxy)
+			t.Setenv("GOPRIVATE", "")
+
+			pkgs, edges := parseFixture(t, parseGoMod(tc.offline), "testdata/golang/mod", "go.mod")
+			packages := map[string]parsedPackage{}
+			for i := range pkgs {
+				packages[pkgs[i].Name+"@"+pkgs[i].Version] = parsedMetadata(&pkgs[i])
+			}
+			if !reflect.DeepEqual(packages, tc.packages) {
+				t.Errorf("packages:\n got %v\nwant %v", packages, tc.packages)
+			}
+			if !reflect.DeepEqual(edges, tc.edges) {
+				t.Errorf("edges:\n got %q\nwant %q", edges, tc.edges)
+			}
+		})
+	}
+}
+
+func TestGoImportScopes(t *testing.T) {
+	resolver := fixtureResolver(t, "testdata/golang/mod")
+
+	for _, tc := range []struct {
+		name     string
+		required []string
+		expected map[string]trusty.Scope
+	}{
+		{
+			// Modules imported from code and tests are runtime, modules
+			// imported by nested modules are skipped
+			name:     "required",
+			required: []string{"example.com/a", "example.com/b", "example.com/local", "example.com/t"},
+			expected: map[string]trusty.Scope{
+				"example.com/a":     trusty.ScopeRuntime,
+				"example.com/local": trusty.ScopeRuntime,
+				"example.com/t":     trusty.ScopeDev,
+			},
+		},
+		{
+			// Imports belong to the longest module path
+			name:     "nested-module",
+			required: []string{"example.com/a", "example.com/a/pkg"},
+			expected: map[string]trusty.Scope{
+				"example.com/a":     trusty.ScopeDev,
+				"example.com/a/pkg": trusty.ScopeRuntime,
+			},
+		},
+		{
+			name:     "not-imported",
+			required: []string{"example.com/b"},
+			expected: map[string]trusty.Scope{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scopes := goImportScopes(resolver, "testdata/golang/mod", tc.required)
+			if !reflect.DeepEqual(scopes, tc.expected) {
+				t.Errorf("got %v, want %v", scopes, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package packages
+
+import (
+	"fmt"
+
+	"github.com/anchore/syft/syft/artifact"
+	"github.com/anchore/syft/syft/pkg"
+	"github.com/google/uuid"
+	"github.com/protobom/protobom/pkg/sbom"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// dependencyMetadata is attached to the packages cataloged by our own
+// parsers to capture data syft's package types don't record.
+type dependencyMetadata struct {
+	// Direct is set when the package is required by the project itself,
+	// not as a dependency of another package.
+	Direct bool
+
+	// Alias is the name under which an npm package was installed
+	// (eg "my-lodash": "npm:lodash@^4")
+	Alias string
+
+	// Scope is the scope in which the project requires the package. It
+	// is used for direct dependencies and for packages whose dependents
+	// are unknown. Blank means runtime.
+	Scope trusty.Scope
+
+	// EdgesUnknown is set when the dependencies of the package could not
+	// be read, the package is left without edges.
+	EdgesUnknown bool
+}
+
+// packageMetadata returns the dependency metadata of a package. Packages
+// cataloged by syft return a blank struct.
+func packageMetadata(p *pkg.Package) dependencyMetadata {
+	if m, ok := p.Metadata.(dependencyMetadata); ok {
+		return m
+	}
+	return dependencyMetadata{}
+}
+
+// dependencyOf returns the syft relationship capturing that parent
+// depends on dep. The scope of the declaration is stored in the
+// relationship data.
+func dependencyOf(dep, parent pkg.Package, scope trusty.Scope) artifact.Relationship {
+	return artifact.Relationship{
+		From: dep,
+		To:   parent,
+		Type: artifact.DependencyOfRelationship,
+		Data: scope,
+	}
+}
+
+// relationshipScope returns the scope stored in a relationship
+func relationshipScope(r *artifact.Relationship) trusty.Scope {
+	if s, ok := r.Data.(trusty.Scope); ok {
+		return s
+	}
+	return trusty.ScopeRuntime
+}
+
+// edgeSet accumulates the edges of a node list, grouping the targets of
+// edges of the same type from the same node.
+type edgeSet struct {
+	order []edgeKey
+	to    map[edgeKey][]string
+}
+
+type edgeKey struct {
+	from     string
+	edgeType sbom.Edge_Type
+}
+
+func (es *edgeSet) add(from string, t sbom.Edge_Type, to string) {
+	k := edgeKey{from: from, edgeType: t}
+	if es.to == nil {
+		es.to = map[edgeKey][]string{}
+	}
+	if _, ok := es.to[k]; !ok {
+		es.order = append(es.order, k)
+	}
+	es.to[k] = append(es.to[k], to)
+}
+
+// addDependency records that parent depends on dep in scope. Scoped edges
+// point from the dependency to the dependent, as the SPDX relationships
+// they map to.
+func (es *edgeSet) addDependency(parent, dep string, scope trusty.Scope) {
+	t := scope.EdgeType()
+	if trusty.DependencyOfEdge(t) {
+		es.add(dep, t, parent)
+		return
+	}
+	es.add(parent, t, dep)
+}
+
+// addTo adds the accumulated edges to the node list
+func (es *edgeSet) addTo(nodeList *sbom.NodeList) {
+	for _, k := range es.order {
+		nodeList.AddEdge(&sbom.Edge{
+			Type: k.edgeType,
+			From: k.from,
+			To:   dedupeIDs(es.to[k]),
+		})
+	}
+}
+
+// catalogResult groups the packages and relationships found by the
+// catalogers of an ecosystem.
+type catalogResult struct {
+	Packages      []pkg.Package
+	Relationships []artifact.Relationship
+}
+
+// addComponentPackages adds the packages of a component to the node list.
+// Each ecosystem gets a subroot node related to the component root. The
+// direct dependencies of the component hang from the subroot and the
+// relationships found in the lockfiles are transferred as dependsOn edges.
+// Packages whose dependents are unknown are related to the subroot too so
+// that the graph stays connected.
+func addComponentPackages(nodeList *sbom.NodeList, componentRoot *sbom.Node, c *Component, catalog map[Ecosystem]*catalogResult) {
+	for _, ecosystem := range c.Ecosystems {
+		subRoot := sbom.NewNode()
+		subRoot.Id = componentRoot.Id + "-" + string(ecosystem)
+		subRoot.Name = string(ecosystem)
+		nodeList.RelateNodeAtID(subRoot, componentRoot.Id, sbom.Edge_dependsOn)
+
+		result, ok := catalog[ecosystem]
+		if !ok {
+			continue
+		}
+
+		// Create the nodes, packages with the same name and version
+		// are deduped into a single node.
+		nodes := map[artifact.ID]*sbom.Node{}
+		deduper := map[string]*sbom.Node{}
+		order := []*sbom.Node{}
+		for i := range result.Packages {
+			p := &result.Packages[i]
+			key := p.Name + "@" + p.Version
+			if n, ok := deduper[key]; ok {
+				nodes[p.ID()] = n
+				continue
+			}
+			n := packageNode(subRoot.Id, p)
+			deduper[key] = n
+			nodes[p.ID()] = n
+			order = append(order, n)
+			nodeList.AddNode(n)
+		}
+
+		// Index the direct dependencies with the most critical scope
+		// they are required in.
+		direct := map[string]trusty.Scope{}
+		for i := range result.Packages {
+			meta := packageMetadata(&result.Packages[i])
+			id := nodes[result.Packages[i].ID()].Id
+			if current, ok := direct[id]; meta.Direct && (!ok || meta.Scope.Rank() < current.Rank()) {
+				direct[id] = meta.Scope
+			}
+		}
+
+		// Transfer the relationships to the node list
+		edges := edgeSet{}
+		hasDependents := map[string]struct{}{}
+		for i := range result.Relationships {
+			r := &result.Relationships[i]
+			if r.Type != artifact.DependencyOfRelationship {
+				continue
+			}
+			dep, ok := nodes[r.From.ID()]
+			if !ok {
+				continue
+			}
+			parent, ok := nodes[r.To.ID()]
+			if !ok || parent.Id == dep.Id {
+				continue
+			}
+			edges.addDependency(parent.Id, dep.Id, relationshipScope(r))
+			hasDependents[dep.Id] = struct{}{}
+		}
+
+		// Direct dependencies and packages with no known dependents
+		// hang from the subroot.
+		rootEdges := edgeSet{}
+		for i := range result.Packages {
+			p := &result.Packages[i]
+			n := nodes[p.ID()]
+			if scope, ok := direct[n.Id]; ok {
+				rootEdges.addDependency(subRoot.Id, n.Id, scope)
+				continue
+			}
+			if _, ok := hasDependents[n.Id]; !ok {
+				rootEdges.addDependency(subRoot.Id, n.Id, packageMetadata(p).Scope)
+			}
+		}
+		rootEdges.addTo(nodeList)
+		edges.addTo(nodeList)
+	}
+}
+
+// dedupeIDs removes duplicates from a list of IDs preserving their order
+func dedupeIDs(ids []string) []string {
+	seen := map[string]struct{}{}
+	ret := []string{}
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ret = append(ret, id)
+	}
+	return ret
+}
+
+// packageNode converts a syft package to a protobom node. The node ID is
+// derived from the ID of the node it hangs from and the package name and
+// version, so reading the same tree gives the same IDs.
+func packageNode(parentID string, p *pkg.Package) *sbom.Node {
+	node := sbom.NewNode()
+	node.Id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(parentID+"/"+p.Name+"@"+p.Version)).String()
+	node.Name = p.Name
+	node.Version = p.Version
+	if len(p.CPEs) >= 1 {
+		node.Identifiers[int32(sbom.SoftwareIdentifierType_CPE23)] = p.CPEs[0].Source.String()
+	}
+	node.Identifiers[int32(sbom.SoftwareIdentifierType_PURL)] = p.PURL
+	for _, l := range p.Licenses.ToSlice() {
+		node.Licenses = append(node.Licenses, l.SPDXExpression)
+	}
+	meta := packageMetadata(p)
+	if meta.Alias != "" {
+		node.Comment = fmt.Sprintf("installed as npm alias %q", meta.Alias)
+	}
+	if meta.EdgesUnknown {
+		node.Comment = "dependencies unknown"
+	}
+	return node
+}
@@ -0,0 +1,870 @@
+package packages
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+	"github.com/anchore/syft/syft/artifact"
+	"github.com/anchore/syft/syft/file"
+	"github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/pkg/cataloger/generic"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// javascriptCatalogers returns the catalogers that read the dependencies
+// of javascript projects and their relationships from their lockfiles.
+func javascriptCatalogers() []pkg.Cataloger {
+	return []pkg.Cataloger{
+		generic.NewCataloger("javascript-package-lock-cataloger").
+			WithParserByGlobs(parsePackageLock, "**/package-lock.json"),
+		generic.NewCataloger("javascript-yarn-lock-cataloger").
+			WithParserByGlobs(parseYarnLock, "**/yarn.lock"),
+		generic.NewCataloger("javascript-pnpm-lock-cataloger").
+			WithParserByGlobs(parsePnpmLock, "**/pnpm-lock.yaml"),
+	}
+}
+
+// localProtocols are the yarn and pnpm protocols that point to code in the
+// tree (workspaces, links, etc). Packages resolved through them are not
+// published in the registry and are never sent to Trusty.
+var localProtocols = []string{"workspace:", "link:", "portal:", "file:", "patch:", "exec:"}
+
+func isLocalSpec(spec string) bool {
+	for _, p := range localProtocols {
+		if strings.Contains(spec, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitNameSpec splits a "name@spec" string taking scoped names into
+// account. The spec is blank if there is no version part.
+func splitNameSpec(s string) (name, spec string) {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	i := strings.Index(strings.TrimPrefix(s, "@"), "@")
+	if i == -1 {
+		return s, ""
+	}
+	if strings.HasPrefix(s, "@") {
+		i++
+	}
+	return s[:i], s[i+1:]
+}
+
+// aliasTarget returns the real package name of an npm alias spec such as
+// "npm:lodash@^4". If the spec is not an alias it returns a blank string.
+func aliasTarget(spec string) string {
+	if !strings.HasPrefix(spec, "npm:") {
+		return ""
+	}
+	name, _ := splitNameSpec(strings.TrimPrefix(spec, "npm:"))
+	return name
+}
+
+// packageLock captures the parts of package-lock.json we read. Lockfile
+// v1 nests the dependency tree in dependencies, v2 and v3 list every
+// installed package by its path in packages.
+type packageLock struct {
+	LockfileVersion int                         `json:"lockfileVersion"`
+	Packages        map[string]packageLockEntry `json:"packages"`
+	Dependencies    map[string]packageLockV1Dep `json:"dependencies"`
+}
+
+type packageLockEntry struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Link                 bool              `json:"link"`
+	Dev                  bool              `json:"dev"`
+	Optional             bool              `json:"optional"`
+	DevOptional          bool              `json:"devOptional"`
+	Peer                 bool              `json:"peer"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+}
+
+// declared returns the dependency lists of the entry by scope. The dev
+// dependencies of installed packages are not installed so they are only
+// returned when withDev is set.
+func (e *packageLockEntry) declared(withDev bool) []scopedDeps {
+	ret := []scopedDeps{
+		{trusty.ScopeRuntime, e.Dependencies},
+		{trusty.ScopeOptional, e.OptionalDependencies},
+		{trusty.ScopePeer, e.PeerDependencies},
+	}
+	if withDev {
+		ret = append(ret, scopedDeps{trusty.ScopeDev, e.DevDependencies})
+	}
+	return ret
+}
+
+// scope returns the scope npm computed for the installed package
+func (e *packageLockEntry) scope() trusty.Scope {
+	return npmFlagsScope(e.Dev || e.DevOptional, e.Optional, e.Peer)
+}
+
+type packageLockV1Dep struct {
+	Version      string                      `json:"version"`
+	Dev          bool                        `json:"dev"`
+	Optional     bool                        `json:"optional"`
+	Requires     map[string]string           `json:"requires"`
+	Dependencies map[string]packageLockV1Dep `json:"dependencies"`
+}
+
+// npmFlagsScope returns the scope of a package from the npm lockfile flags
+func npmFlagsScope(dev, optional, peer bool) trusty.Scope {
+	switch {
+	case dev:
+		return trusty.ScopeDev
+	case peer:
+		return trusty.ScopePeer
+	case optional:
+		return trusty.ScopeOptional
+	default:
+		return trusty.ScopeRuntime
+	}
+}
+
+// scopedDeps is a list of dependencies declared in a scope, indexed by
+// name with their version spec.
+type scopedDeps struct {
+	Scope trusty.Scope
+	Deps  map[string]string
+}
+
+// parsePackageLock reads the packages installed by npm and the dependency
+// tree from package-lock.json. Linked packages (workspaces) are skipped
+// but their dependencies are considered direct dependencies of the project.
+func parsePackageLock(_ context.Context, resolver file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	if pathContainsNodeModules(reader.RealPath) {
+		return nil, nil, nil
+	}
+
+	lock := packageLock{}
+	if err := json.NewDecoder(reader).Decode(&lock); err != nil {
+		return nil, nil, fmt.Errorf("parsing package-lock.json: %w", err)
+	}
+
+	if lock.LockfileVersion < 2 {
+		// v1 hoists transitive dependencies to the top of the tree,
+		// so the direct ones are read from package.json.
+		var declared map[string]trusty.Scope
+		if deps := readPackageJSONDeps(resolver, path.Join(path.Dir(reader.RealPath), "package.json")); deps != nil {
+			declared = directScopes(deps)
+		}
+		pkgs, rels := readPackageLockV1(lock.Dependencies, declared, reader.Location)
+		return pkgs, rels, nil
+	}
+	pkgs, rels := readPackageLockPackages(lock.Packages, reader.Location)
+	return pkgs, rels, nil
+}
+
+// readPackageLockPackages reads the packages section of lockfile v2+
+func readPackageLockPackages(entries map[string]packageLockEntry, location file.Location) ([]pkg.Package, []artifact.Relationship) {
+	// Projects are the root and the workspaces, their deps are direct
+	projects := map[string]struct{}{"": {}}
+	for _, e := range entries {
+		if e.Link && !pathContainsNodeModules(e.Resolved) {
+			projects[e.Resolved] = struct{}{}
+		}
+	}
+
+	direct := map[string]trusty.Scope{}
+	for p := range projects {
+		e, ok := entries[p]
+		if !ok {
+			continue
+		}
+		for name, scope := range directScopes(e.declared(true)) {
+			if target := resolveNodeModule(entries, p, name); target != "" {
+				addScope(direct, target, scope)
+			}
+		}
+	}
+
+	// Create the packages indexed by their path in the tree
+	pkgs := []pkg.Package{}
+	index := map[string]pkg.Package{}
+	for p, e := range entries {
+		if _, ok := projects[p]; ok || e.Link || e.Version == "" || !strings.Contains(p, "node_modules/") {
+			continue
+		}
+		if isLocalSpec(e.Resolved) {
+			continue
+		}
+		installedAs := p[strings.LastIndex(p, "node_modules/")+len("node_modules/"):]
+		meta := dependencyMetadata{Scope: e.scope()}
+		name := installedAs
+		if e.Name != "" && e.Name != installedAs {
+			name = e.Name
+			meta.Alias = installedAs
+		}
+		if scope, ok := direct[p]; ok {
+			meta.Direct = true
+			meta.Scope = scope
+		}
+		index[p] = newNpmPackage(name, e.Version, meta, location)
+		pkgs = append(pkgs, index[p])
+	}
+
+	rels := []artifact.Relationship{}
+	for p, parent := range index {
+		e := entries[p]
+		for _, deps := range e.declared(false) {
+			for name := range deps.Deps {
+				if dep, ok := index[resolveNodeModule(entries, p, name)]; ok {
+					rels = append(rels, dependencyOf(dep, parent, deps.Scope))
+				}
+			}
+		}
+	}
+	return pkgs, rels
+}
+
+// directScopes indexes the dependencies declared by a project by name
+// with the most critical scope they are declared in.
+func directScopes(declared []scopedDeps) map[string]trusty.Scope {
+	ret := map[string]trusty.Scope{}
+	for _, deps := range declared {
+		for name := range deps.Deps {
+			addScope(ret, name, deps.Scope)
+		}
+	}
+	return ret
+}
+
+// addScope records scope for key in the index, unless it already has a
+// more critical one.
+func addScope[K comparable](index map[K]trusty.Scope, key K, scope trusty.Scope) {
+	if current, ok := index[key]; ok && current.Rank() <= scope.Rank() {
+		return
+	}
+	index[key] = scope
+}
+
+// resolveNodeModule finds the path where a dependency required from the
+// package at from is installed, following the node_modules lookup: the
+// nested node_modules directory first, then those of the parent dirs.
+func resolveNodeModule(entries map[string]packageLockEntry, from, name string) string {
+	dir := from
+	for {
+		candidate := path.Join(dir, "node_modules", name)
+		if e, ok := entries[candidate]; ok {
+			if e.Link {
+				// Links point to workspaces in the tree
+				return ""
+			}
+			return candidate
+		}
+		if dir == "" || dir == "." {
+			return ""
+		}
+		dir = path.Dir(dir)
+		if dir == "." {
+			dir = ""
+		}
+	}
+}
+
+// readPackageLockV1 walks the nested dependency tree of lockfile v1. If
+// declared is nil, all the packages at the top of the tree are direct.
+func readPackageLockV1(deps map[string]packageLockV1Dep, declared map[string]trusty.Scope, location file.Location) ([]pkg.Package, []artifact.Relationship) {
+	pkgs := []pkg.Package{}
+	rels := []artifact.Relationship{}
+
+	type scope struct {
+		deps     map[string]packageLockV1Dep
+		packages map[string]pkg.Package
+	}
+
+	// lookup resolves a requirement in the scope chain, innermost first
+	lookup := func(chain []*scope, name string) (pkg.Package, bool) {
+		for i := len(chain) - 1; i >= 0; i-- {
+			if p, ok := chain[i].packages[name]; ok {
+				return p, true
+			}
+			if _, ok := chain[i].deps[name]; ok {
+				return pkg.Package{}, false
+			}
+		}
+		return pkg.Package{}, false
+	}
+
+	var walk func(chain []*scope)
+	walk = func(chain []*scope) {
+		current := chain[len(chain)-1]
+		for installedAs, d := range current.deps {
+			if isLocalSpec(d.Version) {
+				continue
+			}
+			meta := dependencyMetadata{
+				Direct: len(chain) == 1,
+				Scope:  npmFlagsScope(d.Dev, d.Optional, false),
+			}
+			if meta.Direct && declared != nil {
+				var scope trusty.Scope
+				scope, meta.Direct = declared[installedAs]
+				if meta.Direct {
+					meta.Scope = scope
+				}
+			}
+			name, version := installedAs, d.Version
+			if target := aliasTarget(d.Version); target != "" {
+				_, version = splitNameSpec(strings.TrimPrefix(d.Version, "npm:"))
+				name = target
+				meta.Alias = installedAs
+			}
+			current.packages[installedAs] = newNpmPackage(name, version, meta, location)
+			pkgs = append(pkgs, current.packages[installedAs])
+		}
+
+		for installedAs, d := range current.deps {
+			parent, ok := current.packages[installedAs]
+			if !ok {
+				continue
+			}
+			inner := &scope{deps: d.Dependencies, packages: map[string]pkg.Package{}}
+			innerChain := append(append([]*scope{}, chain...), inner)
+			if len(d.Dependencies) > 0 {
+				walk(innerChain)
+			}
+			for req := range d.Requires {
+				if dep, ok := lookup(innerChain, req); ok {
+					rels = append(rels, dependencyOf(dep, parent, trusty.ScopeRuntime))
+				}
+			}
+		}
+	}
+
+	walk([]*scope{{deps: deps, packages: map[string]pkg.Package{}}})
+	return pkgs, rels
+}
+
+// parseYarnLock reads yarn.lock files, both the classic v1 format and the
+// YAML based format of yarn berry (v2+). The direct dependencies are those
+// of the workspaces in berry and those declared in the package.json next
+// to the lockfile in v1.
+func parseYarnLock(_ context.Context, resolver file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	if pathContainsNodeModules(reader.RealPath) {
+		return nil, nil, nil
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading yarn.lock: %w", err)
+	}
+
+	var entries []yarnEntry
+	berry := berryMetadata.Match(data)
+	if berry {
+		entries, err = parseYarnBerry(data)
+	} else {
+		entries, err = parseYarnV1(data)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing yarn.lock: %w", err)
+	}
+
+	// Index the entries by the specifiers that resolve to them
+	specs := map[string]int{}
+	for i := range entries {
+		for _, spec := range entries[i].Specs {
+			specs[spec] = i
+		}
+	}
+	resolve := func(name, spec string) (int, bool) {
+		if i, ok := specs[name+"@"+spec]; ok {
+			return i, true
+		}
+		// Berry records the dependency ranges without the npm: protocol
+		if !strings.Contains(spec, ":") {
+			i, ok := specs[name+"@npm:"+spec]
+			return i, ok
+		}
+		return 0, false
+	}
+
+	// The direct dependencies are read from the package.json files of the
+	// workspaces, which record their scope. In berry, the workspace entries
+	// in the lockfile are used when the manifest is not available.
+	lockDir := path.Dir(reader.RealPath)
+	roots := []scopedDeps{}
+	if berry {
+		for _, e := range entries {
+			if !e.Local {
+				continue
+			}
+			if deps := readPackageJSONDeps(resolver, path.Join(lockDir, e.Workspace, "package.json")); deps != nil {
+				roots = append(roots, deps...)
+				continue
+			}
+			roots = append(roots, e.declared()...)
+		}
+	} else {
+		roots = append(roots, readPackageJSONDeps(resolver, path.Join(lockDir, "package.json"))...)
+	}
+	direct := map[int]trusty.Scope{}
+	for _, deps := range roots {
+		for name, spec := range deps.Deps {
+			if i, ok := resolve(name, spec); ok {
+				addScope(direct, i, deps.Scope)
+			}
+		}
+	}
+
+	// Create the packages, entries of the same package resolved from
+	// different specifiers are folded into one.
+	pkgs := []pkg.Package{}
+	index := map[int]pkg.Package{}
+	seen := map[string]int{}
+	for i, e := range entries {
+		if e.Local || e.Name == "" || e.Version == "" {
+			continue
+		}
+		key := e.Name + "@" + e.Version + "@" + e.Alias
+		if j, ok := seen[key]; ok {
+			if scope, ok := direct[i]; ok {
+				addScope(direct, j, scope)
+			}
+			continue
+		}
+		seen[key] = i
+	}
+	for i, e := range entries {
+		j, ok := seen[e.Name+"@"+e.Version+"@"+e.Alias]
+		if !ok || e.Local {
+			continue
+		}
+		if _, ok := index[j]; !ok {
+			scope, isDirect := direct[j]
+			index[j] = newNpmPackage(e.Name, e.Version, dependencyMetadata{Direct: isDirect, Alias: e.Alias, Scope: scope}, reader.Location)
+			pkgs = append(pkgs, index[j])
+		}
+		index[i] = index[j]
+	}
+
+	rels := []artifact.Relationship{}
+	for i, e := range entries {
+		parent, ok := index[i]
+		if !ok {
+			continue
+		}
+		for _, deps := range e.declared() {
+			for name, spec := range deps.Deps {
+				if j, ok := resolve(name, spec); ok {
+					if dep, ok := index[j]; ok {
+						rels = append(rels, dependencyOf(dep, parent, deps.Scope))
+					}
+				}
+			}
+		}
+	}
+	return pkgs, rels, nil
+}
+
+// readPackageJSONDeps returns the dependency lists declared in a
+// package.json file. If the file cannot be read, it returns nothing.
+func readPackageJSONDeps(resolver file.Resolver, p string) []scopedDeps {
+	locations, err := resolver.FilesByPath(p)
+	if err != nil || len(locations) == 0 {
+		return nil
+	}
+	r, err := resolver.FileContentsByLocation(locations[0])
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	manifest := struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+	}{}
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil
+	}
+	return []scopedDeps{
+		{trusty.ScopeRuntime, manifest.Dependencies},
+		{trusty.ScopeDev, manifest.DevDependencies},
+		{trusty.ScopeOptional, manifest.OptionalDependencies},
+		{trusty.ScopePeer, manifest.PeerDependencies},
+	}
+}
+
+// yarnEntry is a resolved package in a yarn lockfile
+type yarnEntry struct {
+	Name                 string
+	Alias                string
+	Version              string
+	Local                bool
+	Workspace            string
+	Specs                []string
+	Dependencies         map[string]string
+	OptionalDependencies map[string]string
+}
+
+// declared returns the dependency lists of the entry by scope
+func (e *yarnEntry) declared() []scopedDeps {
+	return []scopedDeps{
+		{trusty.ScopeRuntime, e.Dependencies},
+		{trusty.ScopeOptional, e.OptionalDependencies},
+	}
+}
+
+var berryMetadata = regexp.MustCompile(`(?m)^__metadata:`)
+
+// yarnBerryEntry is an entry of a yarn berry lockfile
+type yarnBerryEntry struct {
+	Version          string            `yaml:"version"`
+	Resolution       string            `yaml:"resolution"`
+	Dependencies     map[string]string `yaml:"dependencies"`
+	DependenciesMeta map[string]struct {
+		Optional bool `yaml:"optional"`
+	} `yaml:"dependenciesMeta"`
+}
+
+func parseYarnBerry(data []byte) ([]yarnEntry, error) {
+	lock := map[string]yarnBerryEntry{}
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	ret := []yarnEntry{}
+	for key, entry := range lock {
+		if key == "__metadata" {
+			continue
+		}
+		// The resolution has the real package name, eg "lodash@npm:4.17.21"
+		name, resolved := splitNameSpec(entry.Resolution)
+		e := yarnEntry{
+			Name:                 name,
+			Version:              entry.Version,
+			Local:                isLocalSpec(resolved),
+			Dependencies:         map[string]string{},
+			OptionalDependencies: map[string]string{},
+		}
+		if strings.HasPrefix(resolved, "workspace:") {
+			e.Workspace = strings.TrimPrefix(resolved, "workspace:")
+		}
+		// Optional dependencies are flagged in the dependencies meta
+		for dep, spec := range entry.Dependencies {
+			if entry.DependenciesMeta[dep].Optional {
+				e.OptionalDependencies[dep] = spec
+				continue
+			}
+			e.Dependencies[dep] = spec
+		}
+		for _, spec := range strings.Split(key, ",") {
+			e.Specs = append(e.Specs, strings.TrimSpace(spec))
+		}
+		keyName, _ := splitNameSpec(e.Specs[0])
+		if keyName != name {
+			e.Alias = keyName
+		}
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+var (
+	yarnV1Field = regexp.MustCompile(`^  (\w+) "?([^"]*)"?$`)
+	yarnV1Dep   = regexp.MustCompile(`^    "?([^"\s]+)"? "?([^"]*)"?$`)
+)
+
+func parseYarnV1(data []byte) ([]yarnEntry, error) {
+	ret := []yarnEntry{}
+	var current *yarnEntry
+	var section map[string]string
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#"):
+			continue
+		case !strings.HasPrefix(line, " "):
+			// New entry: "name@spec", name@spec:
+			if current != nil {
+				ret = append(ret, *current)
+			}
+			specs := strings.Split(strings.TrimSuffix(line, ":"), ",")
+			name, spec := splitNameSpec(specs[0])
+			current = &yarnEntry{
+				Name:                 name,
+				Local:                isLocalSpec(spec),
+				Dependencies:         map[string]string{},
+				OptionalDependencies: map[string]string{},
+			}
+			for _, s := range specs {
+				current.Specs = append(current.Specs, strings.Trim(strings.TrimSpace(s), `"`))
+			}
+			if target := aliasTarget(spec); target != "" {
+				current.Alias = name
+				current.Name = target
+			}
+			section = nil
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "    ") && section != nil:
+			if m := yarnV1Dep.FindStringSubmatch(line); m != nil {
+				section[m[1]] = m[2]
+			}
+		default:
+			switch strings.TrimSpace(line) {
+			case "dependencies:":
+				section = current.Dependencies
+			case "optionalDependencies:":
+				section = current.OptionalDependencies
+			default:
+				section = nil
+			}
+			if m := yarnV1Field.FindStringSubmatch(line); m != nil && m[1] == "version" {
+				current.Version = m[2]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		ret = append(ret, *current)
+	}
+	return ret, nil
+}
+
+// pnpmLock captures the parts of pnpm-lock.yaml we read
+type pnpmLock struct {
+	Version   string                    `yaml:"lockfileVersion"`
+	Importers map[string]pnpmImporter   `yaml:"importers"`
+	Packages  map[string]map[string]any `yaml:"packages"`
+	Snapshots map[string]map[string]any `yaml:"snapshots"`
+	Deps      map[string]any            `yaml:"dependencies"`
+	DevDeps   map[string]any            `yaml:"devDependencies"`
+	Optional  map[string]any            `yaml:"optionalDependencies"`
+}
+
+type pnpmImporter struct {
+	Dependencies         map[string]any `yaml:"dependencies"`
+	DevDependencies      map[string]any `yaml:"devDependencies"`
+	OptionalDependencies map[string]any `yaml:"optionalDependencies"`
+}
+
+// pnpmImporterDeps is a dependency list of an importer with its scope
+type pnpmImporterDeps struct {
+	Scope trusty.Scope
+	Deps  map[string]any
+}
+
+// declared returns the dependency lists of the importer by scope
+func (imp *pnpmImporter) declared() []pnpmImporterDeps {
+	return []pnpmImporterDeps{
+		{trusty.ScopeRuntime, imp.Dependencies},
+		{trusty.ScopeDev, imp.DevDependencies},
+		{trusty.ScopeOptional, imp.OptionalDependencies},
+	}
+}
+
+// pnpmPeerSuffix matches the peer dependency suffixes of pnpm v6+ package
+// keys, eg "(react@18.2.0)". The v5 suffixes ("_react@18.2.0") are cut at
+// the underscore once the version is split from the key.
+var pnpmPeerSuffix = regexp.MustCompile(`(\(.*\))+$`)
+
+// parsePnpmPackageKey returns the name and version from a key in the
+// packages section of pnpm-lock.yaml
+func parsePnpmPackageKey(key string, lockVersion float64) (name, version string) {
+	key = pnpmPeerSuffix.ReplaceAllString(strings.TrimPrefix(key, "/"), "")
+	if lockVersion < 6 {
+		i := strings.LastIndex(key, "/")
+		if i == -1 {
+			return "", ""
+		}
+		return key[:i], strings.SplitN(key[i+1:], "_", 2)[0]
+	}
+	return splitNameSpec(key)
+}
+
+// pnpmDependencyVersion returns the resolved version of an importer
+// dependency, which is either a string or a map in lockfile v6+
+func pnpmDependencyVersion(info any) string {
+	switch v := info.(type) {
+	case string:
+		return v
+	case map[string]any:
+		if s, ok := v["version"].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// pnpmDependencyKey returns the package a dependency reference points to.
+// References are versions, possibly with a peer suffix, or package keys
+// when the dependency is aliased: /lodash/4.17.21 (v5), /lodash@4.17.21
+// (v6) or lodash@4.17.21 (v9).
+func pnpmDependencyKey(name, ref string, lockVersion float64) (string, string) {
+	ref = pnpmPeerSuffix.ReplaceAllString(ref, "")
+	if strings.HasPrefix(ref, "/") || (lockVersion >= 6 && strings.Contains(strings.TrimPrefix(ref, "@"), "@")) {
+		return parsePnpmPackageKey(ref, lockVersion)
+	}
+	if lockVersion < 6 {
+		ref = strings.SplitN(ref, "_", 2)[0]
+	}
+	return name, ref
+}
+
+// parsePnpmLock reads the packages in a pnpm-lock.yaml file and their
+// dependencies. The dependencies of the importers (the project and its
+// workspaces) are the direct dependencies. Workspace packages are only
+// linked from the importers so they are never listed.
+func parsePnpmLock(_ context.Context, _ file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	if pathContainsNodeModules(reader.RealPath) {
+		return nil, nil, nil
+	}
+
+	lock := pnpmLock{}
+	if err := yaml.NewDecoder(reader).Decode(&lock); err != nil {
+		return nil, nil, fmt.Errorf("parsing pnpm-lock.yaml: %w", err)
+	}
+	lockVersion, err := strconv.ParseFloat(strings.Trim(lock.Version, `'"`), 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading pnpm lockfile version %q: %w", lock.Version, err)
+	}
+
+	// Index the direct and aliased dependencies from the importers. Before
+	// lockfile v5.4 there are no importers, only top level dependency lists.
+	importers := lock.Importers
+	if importers == nil {
+		importers = map[string]pnpmImporter{".": {
+			Dependencies:         lock.Deps,
+			DevDependencies:      lock.DevDeps,
+			OptionalDependencies: lock.Optional,
+		}}
+	}
+	aliases := map[string]string{}
+	direct := map[string]trusty.Scope{}
+	for _, imp := range importers {
+		for _, deps := range imp.declared() {
+			for alias, info := range deps.Deps {
+				version := pnpmDependencyVersion(info)
+				if isLocalSpec(version) {
+					continue
+				}
+				name, v := pnpmDependencyKey(alias, version, lockVersion)
+				if name == "" || v == "" {
+					continue
+				}
+				addScope(direct, name+"@"+v, deps.Scope)
+				if name != alias {
+					aliases[name+"@"+v] = alias
+				}
+			}
+		}
+	}
+
+	pkgs := []pkg.Package{}
+	index := map[string]pkg.Package{}
+	for key, entry := range lock.Packages {
+		if isLocalSpec(key) {
+			continue
+		}
+		name, version := parsePnpmPackageKey(key, lockVersion)
+		if name == "" || version == "" {
+			continue
+		}
+		id := name + "@" + version
+		if _, ok := index[id]; ok {
+			continue
+		}
+		// Before v9, pnpm flags the dev and optional packages
+		dev, _ := entry["dev"].(bool)
+		optional, _ := entry["optional"].(bool)
+		meta := dependencyMetadata{Alias: aliases[id], Scope: npmFlagsScope(dev, optional, false)}
+		if scope, ok := direct[id]; ok {
+			meta.Direct = true
+			meta.Scope = scope
+		}
+		index[id] = newNpmPackage(name, version, meta, reader.Location)
+		pkgs = append(pkgs, index[id])
+	}
+
+	// Lockfile v9 moved the dependencies of each package to snapshots
+	resolved := lock.Packages
+	if lock.Snapshots != nil {
+		resolved = lock.Snapshots
+	}
+	rels := []artifact.Relationship{}
+	for key, entry := range resolved {
+		name, version := parsePnpmPackageKey(key, lockVersion)
+		parent, ok := index[name+"@"+version]
+		if !ok {
+			continue
+		}
+		for field, scope := range map[string]trusty.Scope{
+			"dependencies":         trusty.ScopeRuntime,
+			"optionalDependencies": trusty.ScopeOptional,
+		} {
+			deps, ok := entry[field].(map[string]any)
+			if !ok {
+				continue
+			}
+			for depName, ref := range deps {
+				r, ok := ref.(string)
+				if !ok || isLocalSpec(r) {
+					continue
+				}
+				name, version := pnpmDependencyKey(depName, r, lockVersion)
+				if dep, ok := index[name+"@"+version]; ok {
+					rels = append(rels, dependencyOf(dep, parent, scope))
+				}
+			}
+		}
+	}
+	return pkgs, rels, nil
+}
+
+func pathContainsNodeModules(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		if part == "node_modules" {
+			return true
+		}
+	}
+	return false
+}
+
+// newNpmPackage returns an npm package found at location
+func newNpmPackage(name, version string, meta dependencyMetadata, location file.Location) pkg.Package {
+	namespace := ""
+	pname := name
+	if strings.HasPrefix(name, "@") && strings.Contains(name, "/") {
+		namespace, pname, _ = strings.Cut(name, "/")
+	}
+	p := pkg.Package{
+		Name:      name,
+		Version:   version,
+		Locations: file.NewLocationSet(location),
+		PURL: packageurl.NewPackageURL(
+			packageurl.TypeNPM, namespace, pname, version, nil, "",
+		).ToString(),
+		Language: pkg.JavaScript,
+		Type:     pkg.NpmPkg,
+	}
+	if meta != (dependencyMetadata{}) {
+		p.Metadata = meta
+	}
+	p.SetID()
+	return p
+}
@@ -0,0 +1,148 @@
+package packages
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+
+	"github.com/anchore/syft/syft/pkg/cataloger/generic"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// The fixtures describe the same project in every lockfile format: it
+// requires a (runtime), d (dev), o (optional) and lodash installed as
+// my-lodash. a depends on b@2 and optionally on c, d depends on b@1. The pnpm
+// fixtures add e, installed with its peer r, as a dependency of a, and the
+// npm v2+ fixtures the peer dependency p.
+var (
+	jsDirect = map[string]parsedPackage{
+		"a@1.0.0":        {Direct: true, Scope: trusty.ScopeRuntime},
+		"d@1.0.0":        {Direct: true, Scope: trusty.ScopeDev},
+		"o@1.0.0":        {Direct: true, Scope: trusty.ScopeOptional},
+		"lodash@4.17.21": {Direct: true, Scope: trusty.ScopeRuntime, Alias: "my-lodash"},
+	}
+	jsEdges = []string{
+		"a@1.0.0 -> b@2.0.0 (runtime)",
+		"a@1.0.0 -> c@1.0.0 (optional)",
+		"d@1.0.0 -> b@1.0.0 (runtime)",
+	}
+	pnpmEdges = []string{
+		"a@1.0.0 -> b@2.0.0 (runtime)",
+		"a@1.0.0 -> c@1.0.0 (optional)",
+		"a@1.0.0 -> e@1.0.0 (runtime)",
+		"d@1.0.0 -> b@1.0.0 (runtime)",
+		"e@1.0.0 -> r@1.0.0 (runtime)",
+	}
+)
+
+// withPackages returns the direct dependencies of the fixture project
+// along with the extra packages passed
+func withPackages(extra ...map[string]parsedPackage) map[string]parsedPackage {
+	ret := maps.Clone(jsDirect)
+	for _, e := range extra {
+		maps.Copy(ret, e)
+	}
+	return ret
+}
+
+func TestParseJavascriptLockfiles(t *testing.T) {
+	// Transitive packages flagged in the lockfile keep the flag scope
+	flagged := map[string]parsedPackage{
+		"b@1.0.0": {Scope: trusty.ScopeDev},
+		"b@2.0.0": {Scope: trusty.ScopeRuntime},
+		"c@1.0.0": {Scope: trusty.ScopeOptional},
+	}
+	// yarn and pnpm v9 don't flag packages, their scope comes from the graph
+	unflagged := map[string]parsedPackage{
+		"b@1.0.0": {Scope: trusty.ScopeRuntime},
+		"b@2.0.0": {Scope: trusty.ScopeRuntime},
+		"c@1.0.0": {Scope: trusty.ScopeRuntime},
+	}
+	// npm v7+ installs the peer dependencies of the project
+	npmPeer := map[string]parsedPackage{
+		"p@1.0.0": {Direct: true, Scope: trusty.ScopePeer},
+	}
+	// pnpm installs e with its peer r
+	peers := map[string]parsedPackage{
+		"e@1.0.0": {Scope: trusty.ScopeRuntime},
+		"r@1.0.0": {Scope: trusty.ScopeRuntime},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		parser   generic.Parser
+		lockfile string
+		packages map[string]parsedPackage
+		edges    []string
+	}{
+		{
+			name: "npm-v1", parser: parsePackageLock, lockfile: "package-lock.json",
+			packages: withPackages(flagged),
+			// v1 requires don't tell optional dependencies apart
+			edges: []string{
+				"a@1.0.0 -> b@2.0.0 (runtime)",
+				"a@1.0.0 -> c@1.0.0 (runtime)",
+				"d@1.0.0 -> b@1.0.0 (runtime)",
+			},
+		},
+		{
+			// The v1 dependencies kept for old npm versions are ignored
+			name: "npm-v2", parser: parsePackageLock, lockfile: "package-lock.json",
+			packages: withPackages(flagged, npmPeer),
+			edges:    jsEdges,
+		},
+		{
+			name: "npm-v3", parser: parsePackageLock, lockfile: "package-lock.json",
+			packages: withPackages(flagged, npmPeer),
+			edges:    jsEdges,
+		},
+		{
+			name: "yarn-v1", parser: parseYarnLock, lockfile: "yarn.lock",
+			packages: withPackages(unflagged),
+			edges:    jsEdges,
+		},
+		{
+			// The tools workspace has no package.json, its dependencies
+			// are read from the lockfile
+			name: "yarn-berry", parser: parseYarnLock, lockfile: "yarn.lock",
+			packages: withPackages(unflagged, map[string]parsedPackage{
+				"e@1.0.0": {Direct: true, Scope: trusty.ScopeRuntime},
+			}),
+			edges: jsEdges,
+		},
+		{
+			name: "pnpm-v5", parser: parsePnpmLock, lockfile: "pnpm-lock.yaml",
+			packages: withPackages(flagged, peers),
+			edges:    pnpmEdges,
+		},
+		{
+			name: "pnpm-v6", parser: parsePnpmLock, lockfile: "pnpm-lock.yaml",
+			packages: withPackages(flagged, peers),
+			edges:    pnpmEdges,
+		},
+		{
+			name: "pnpm-v9", parser: parsePnpmLock, lockfile: "pnpm-lock.yaml",
+			packages: withPackages(unflagged, peers),
+			edges:    pnpmEdges,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pkgs, edges := parseFixture(t, tc.parser, "testdata/javascript/"+tc.name, tc.lockfile)
+			packages := map[string]parsedPackage{}
+			for i := range pkgs {
+				p := &pkgs[i]
+				if want := "pkg:npm/" + p.Name + "@" + p.Version; p.PURL != want {
+					t.Errorf("%s@%s: purl %q, want %q", p.Name, p.Version, p.PURL, want)
+				}
+				packages[p.Name+"@"+p.Version] = parsedMetadata(p)
+			}
+			if !reflect.DeepEqual(packages, tc.packages) {
+				t.Errorf("packages:\n got %v\nwant %v", packages, tc.packages)
+			}
+			if !reflect.DeepEqual(edges, tc.edges) {
+				t.Errorf("edges:\n got %q\nwant %q", edges, tc.edges)
+			}
+		})
+	}
+}
@@ -3,20 +3,21 @@ package packages
 import (
 	"context"
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/anchore/syft/syft/artifact"
 	"github.com/anchore/syft/syft/file"
 	"github.com/anchore/syft/syft/pkg"
-	"github.com/anchore/syft/syft/pkg/cataloger/golang"
-	"github.com/anchore/syft/syft/pkg/cataloger/javascript"
-	"github.com/anchore/syft/syft/pkg/cataloger/python"
 	"github.com/anchore/syft/syft/source"
 	"github.com/anchore/syft/syft/source/directorysource"
-	"github.com/google/uuid"
 	"github.com/protobom/protobom/pkg/sbom"
+	"github.com/sirupsen/logrus"
 	"github.com/stacklok/trusty-sdk-go/pkg/types"
 
-	"sigs.k8s.io/release-sdk/git"
 	"sigs.k8s.io/release-utils/util"
 )
 
@@ -40,106 +41,379 @@ func (e Ecosystem) ToTrusty() types.Ecosystem {
 	return 0
 }
 
-type Lister struct{}
+// Options controls how the lister discovers and reads packages
+type Options struct {
+	// Recursive makes the lister walk the directory tree looking for
+	// components instead of only inspecting the top directory.
+	Recursive bool
+
+	// Include and Exclude are globs matched against the directories in the
+	// tree, relative to the scanned path. Bare names match at any depth.
+	Include []string
+	Exclude []string
+
+	// Revision is a git commit, tag or branch to read the packages from.
+	// The files are read from the git object database, without checking
+	// out the revision. When blank, the files on disk are read.
+	Revision string
+
+	// Offline stops the lister from downloading the go.mod files of the
+	// modules missing from the local module cache from the module proxy.
+	// Their dependencies are left unknown.
+	Offline bool
+}
+
+// DefaultExclude lists the directories skipped unless the exclusions are
+// overridden: vendored and installed dependencies and test fixtures.
+var DefaultExclude = []string{"vendor", "node_modules", "testdata"}
+
+var defaultOptions = Options{
+	Exclude: DefaultExclude,
+}
+
+type Lister struct {
+	Options Options
+}
 
 func NewLister() *Lister {
-	return &Lister{}
+	opts := defaultOptions
+	opts.Exclude = append([]string{}, defaultOptions.Exclude...)
+	return &Lister{
+		Options: opts,
+	}
 }
 
-// GuessEcosystem looks at a directory and tries to guess what kind of code lives
-// there.
-func (l *Lister) GuessEcosystem(path string) (Ecosystem, error) {
+// ecosystemManifests lists the files that signal the presence of code of
+// each ecosystem in a directory
+var ecosystemManifests = []struct {
+	Ecosystem Ecosystem
+	Files     []string
+}{
+	{Python, []string{"requirements.txt", "pyproject.toml", "poetry.lock", "Pipfile.lock", "uv.lock"}},
+	{Go, []string{"go.mod"}},
+	{Npm, []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml"}},
+}
+
+// GuessEcosystems looks at a directory and returns all the ecosystems of
+// the code that lives there.
+func (l *Lister) GuessEcosystems(path string) ([]Ecosystem, error) {
 	if !util.Exists(path) {
-		return "", fmt.Errorf("specified directory doesn't exist")
+		return nil, fmt.Errorf("specified directory doesn't exist")
 	}
 
-	switch {
-	case util.Exists(filepath.Join(path, "requirements.txt")):
-		return Python, nil
-	case util.Exists(filepath.Join(path, "go.mod")):
-		return Go, nil
-	case util.Exists(filepath.Join(path, "package-lock.json")):
-		return Npm, nil
-	default:
-		return "", nil
+	ret := []Ecosystem{}
+	for _, em := range ecosystemManifests {
+		for _, f := range em.Files {
+			if util.Exists(filepath.Join(path, f)) {
+				ret = append(ret, em.Ecosystem)
+				break
+			}
+		}
 	}
+	return ret, nil
+}
+
+// ComponentPackages is the list of dependencies of a component
+type ComponentPackages struct {
+	Component
+	Project  *Project
+	NodeList *sbom.NodeList
 }
 
-// ReadPackages extracts the dependencies of a project
-func (l *Lister) ReadPackages(ctx context.Context, path string) (*sbom.NodeList, error) {
-	ecosystem, err := l.GuessEcosystem(path)
+// Source is a project tree opened for reading: the directory on disk or,
+// when the lister reads a revision, its files exported from git. The
+// revision is resolved once, when the source is opened, so the packages
+// and the project identity are read from the same commit.
+type Source struct {
+	// Path is the path to the project as passed to Open
+	Path string
+
+	dir     string
+	rev     *gitRevision
+	cleanup func()
+}
+
+// Open resolves the revision in the options of the project at path and
+// returns the source to read it. It must be closed when done to remove the
+// files exported from the revision.
+func (l *Lister) Open(path string) (*Source, error) {
+	dir, rev, cleanup, err := l.openSource(path)
 	if err != nil {
-		return nil, fmt.Errorf("reading packages: %w", err)
+		return nil, err
 	}
+	return &Source{Path: path, dir: dir, rev: rev, cleanup: cleanup}, nil
+}
+
+// Close removes the temporary files of the source
+func (s *Source) Close() {
+	s.cleanup()
+}
 
-	cataloger := getCataloger(ecosystem)
-	if cataloger == nil {
-		return nil, fmt.Errorf("ecosystem no yet supported")
+// Project reads the project identity from the manifest at the top of the
+// source and its git revision.
+func (s *Source) Project() *Project {
+	return identifyProject(s.rev, dirReader(s.dir), s.Path)
+}
+
+// RepoPath returns the path of the source relative to the root of its git
+// repository, see RepoPath
+func (s *Source) RepoPath() string {
+	if s.rev != nil && s.rev.path != "" {
+		return s.rev.path
 	}
+	return RepoPath(s.Path)
+}
 
-	solver, err := getResolver(path)
+// ReadPackages extracts the dependencies of a project. All the components
+// found are cataloged into a single node list. Components hang from the
+// project root, each ecosystem in a component gets its own subroot node
+// where its packages are related.
+func (l *Lister) ReadPackages(ctx context.Context, src *Source) (*sbom.NodeList, error) {
+	components, catalog, err := l.catalogComponents(ctx, src.dir)
 	if err != nil {
 		return nil, err
 	}
 
-	name, err := getProjectName(path)
+	nodeList := sbom.NewNodeList()
+	rootNode := projectNode(src.Project())
+	nodeList.AddRootNode(rootNode)
+
+	for i := range components {
+		componentRoot := rootNode
+		if components[i].Path != "." {
+			componentRoot = sbom.NewNode()
+			componentRoot.Id = components[i].ID()
+			componentRoot.Name = components[i].Path
+			nodeList.RelateNodeAtID(componentRoot, rootNode.Id, sbom.Edge_dependsOn)
+		}
+		addComponentPackages(nodeList, componentRoot, &components[i], catalog[components[i].Path])
+	}
+
+	return nodeList, nil
+}
+
+// ReadComponents extracts the dependencies of a project, returning a
+// separate node list for each component found.
+func (l *Lister) ReadComponents(ctx context.Context, src *Source) ([]ComponentPackages, error) {
+	components, catalog, err := l.catalogComponents(ctx, src.dir)
 	if err != nil {
 		return nil, err
 	}
 
-	packages, _, err := cataloger.Catalog(ctx, solver)
+	top := src.Project()
+
+	ret := []ComponentPackages{}
+	for i := range components {
+		project := top
+		if components[i].Path != "." {
+			project = componentProject(top, src.rev, filepath.Join(src.dir, components[i].Path), components[i].Path)
+		}
+		nodeList := sbom.NewNodeList()
+		rootNode := projectNode(project)
+		nodeList.AddRootNode(rootNode)
+		addComponentPackages(nodeList, rootNode, &components[i], catalog[components[i].Path])
+		ret = append(ret, ComponentPackages{
+			Component: components[i],
+			Project:   project,
+			NodeList:  nodeList,
+		})
+	}
+	return ret, nil
+}
+
+// componentProject identifies a component in a subdirectory of the project.
+// Components without a manifest naming them are named after the top
+// project and their path.
+func componentProject(top *Project, rev *gitRevision, dir, rel string) *Project {
+	p := identifyProject(rev, dirReader(dir), dir)
+	if p.Purl == "" {
+		p.Name = top.Name + "#" + rel
+	}
+	return p
+}
+
+// projectNode returns the root node describing the project
+func projectNode(p *Project) *sbom.Node {
+	node := sbom.NewNode()
+	node.Id = "root"
+	node.Name = p.Name
+	node.Version = p.Version
+	if p.Purl != "" {
+		node.Identifiers[int32(sbom.SoftwareIdentifierType_PURL)] = p.Purl
+	}
+	return node
+}
+
+// openSource returns the directory to catalog and the git revision of
+// path, which is nil if path is not in a git repository. When the lister
+// is set to read a revision, its manifests and lockfiles are exported to a
+// temporary directory, removed by the returned cleanup function.
+func (l *Lister) openSource(path string) (string, *gitRevision, func(), error) {
+	if l.Options.Revision == "" {
+		rev, err := openRevision(path, "")
+		if err != nil {
+			logrus.Debugf("%s is not in a git repository: %v", path, err)
+			rev = nil
+		}
+		return path, rev, func() {}, nil
+	}
+
+	if !util.Exists(path) {
+		return "", nil, nil, fmt.Errorf("specified directory doesn't exist")
+	}
+
+	rev, err := openRevision(path, l.Options.Revision)
 	if err != nil {
-		return nil, fmt.Errorf("scanning for packages: %w", err)
+		return "", nil, nil, err
 	}
 
-	nodeList := sbom.NewNodeList()
+	dir, err := os.MkdirTemp("", "trusty-rev-")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("creating temporary directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
 
-	rootNode := sbom.NewNode()
-	rootNode.Name = name
-	rootNode.Id = "root"
-	nodeList.AddRootNode(rootNode)
-	deduper := map[string]map[string]struct{}{}
-	for _, p := range packages {
-		if _, ok := deduper[p.Name]; ok {
-			if _, ok := deduper[p.Name][p.Version]; ok {
-				continue
+	if err := rev.export(dir, l.isSourceFile); err != nil {
+		cleanup()
+		return "", nil, nil, fmt.Errorf("reading files at %s: %w", l.Options.Revision, err)
+	}
+	return dir, rev, cleanup, nil
+}
+
+// sourceFiles are the files read by the catalogers, in addition to the
+// ecosystem manifests
+var sourceFiles = []string{"package.json", "setup.py", "Pipfile"}
+
+// isSourceFile returns true if a file in the tree is read by the lister
+// and is not in an excluded directory.
+func (l *Lister) isSourceFile(name string) bool {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if l.excluded(dir) {
+			return false
+		}
+	}
+
+	base := path.Base(name)
+	if slices.Contains(sourceFiles, base) {
+		return true
+	}
+	if strings.HasSuffix(base, ".txt") && strings.Contains(base, "requirements") {
+		return true
+	}
+	// Go sources tell apart the modules only required by tests
+	if strings.HasSuffix(base, ".go") {
+		return true
+	}
+	for _, em := range ecosystemManifests {
+		if slices.Contains(em.Files, base) {
+			return true
+		}
+	}
+	return false
+}
+
+// catalogComponents finds the components in path and catalogs their
+// packages. The packages and their relationships are returned indexed by
+// component path and ecosystem.
+func (l *Lister) catalogComponents(ctx context.Context, path string) ([]Component, map[string]map[Ecosystem]*catalogResult, error) {
+	components, err := l.FindComponents(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading packages: %w", err)
+	}
+
+	if len(components) == 0 {
+		return nil, nil, fmt.Errorf("no supported ecosystem found")
+	}
+
+	// Index the ecosystems expected in each directory
+	expected := map[string]map[Ecosystem]struct{}{}
+	ecosystems := []Ecosystem{}
+	for _, c := range components {
+		expected[c.Path] = map[Ecosystem]struct{}{}
+		for _, e := range c.Ecosystems {
+			expected[c.Path][e] = struct{}{}
+			if !slices.Contains(ecosystems, e) {
+				ecosystems = append(ecosystems, e)
 			}
-		} else {
-			deduper[p.Name] = map[string]struct{}{}
 		}
-		node := sbom.NewNode()
-		node.Id = uuid.NewString()
-		node.Name = p.Name
-		node.Version = p.Version
-		if len(p.CPEs) >= 1 {
-			node.Identifiers[int32(sbom.SoftwareIdentifierType_CPE23)] = p.CPEs[0].Source.String()
+	}
+
+	solver, err := l.getResolver(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	catalog := map[string]map[Ecosystem]*catalogResult{}
+	for _, ecosystem := range ecosystems {
+		catalogers := l.getCatalogers(ecosystem)
+		if len(catalogers) == 0 {
+			return nil, nil, fmt.Errorf("ecosystem %s no yet supported", ecosystem)
+		}
+
+		packages := []pkg.Package{}
+		relationships := []artifact.Relationship{}
+		for _, cataloger := range catalogers {
+			found, rels, err := cataloger.Catalog(ctx, solver)
+			if err != nil {
+				return nil, nil, fmt.Errorf("scanning for %s packages: %w", ecosystem, err)
+			}
+			packages = append(packages, found...)
+			relationships = append(relationships, rels...)
 		}
-		node.Identifiers[int32(sbom.SoftwareIdentifierType_PURL)] = p.PURL
-		for _, l := range p.Licenses.ToSlice() {
-			node.Licenses = append(node.Licenses, l.SPDXExpression)
+
+		// Assign each package to the component of its manifest. Packages
+		// outside of the components are ignored.
+		owners := map[artifact.ID]*catalogResult{}
+		for _, p := range packages {
+			locations := p.Locations.ToSlice()
+			if len(locations) == 0 {
+				continue
+			}
+			dir := manifestDir(locations[0].RealPath)
+			if _, ok := expected[dir][ecosystem]; !ok {
+				continue
+			}
+			if _, ok := catalog[dir]; !ok {
+				catalog[dir] = map[Ecosystem]*catalogResult{}
+			}
+			if _, ok := catalog[dir][ecosystem]; !ok {
+				catalog[dir][ecosystem] = &catalogResult{}
+			}
+			catalog[dir][ecosystem].Packages = append(catalog[dir][ecosystem].Packages, p)
+			owners[p.ID()] = catalog[dir][ecosystem]
+		}
+
+		// Relationships go with the component of the dependent package
+		for _, r := range relationships {
+			if owner, ok := owners[r.To.ID()]; ok {
+				owner.Relationships = append(owner.Relationships, r)
+			}
 		}
-		deduper[p.Name][p.Version] = struct{}{}
-		nodeList.RelateNodeAtID(node, "root", sbom.Edge_dependsOn)
 	}
 
-	return nodeList, nil
+	return components, catalog, nil
 }
 
-func getCataloger(e Ecosystem) pkg.Cataloger {
+func (l *Lister) getCatalogers(e Ecosystem) []pkg.Cataloger {
 	switch e {
 	case Python:
-		return python.NewInstalledPackageCataloger()
+		return pythonCatalogers()
 	case Go:
-		return golang.NewGoModuleFileCataloger(golang.DefaultCatalogerConfig())
+		return golangCatalogers(&l.Options)
 	case Npm:
-		return javascript.NewLockCataloger(javascript.DefaultCatalogerConfig())
+		return javascriptCatalogers()
 	default:
 		return nil
 	}
 }
 
-func getResolver(path string) (file.Resolver, error) {
-	src, err := directorysource.NewFromPath(path)
+func (l *Lister) getResolver(path string) (file.Resolver, error) {
+	src, err := directorysource.New(directorysource.Config{
+		Path: path,
+		Exclude: source.ExcludeConfig{
+			Paths: l.resolverExclusions(),
+		},
+	})
 	if err != nil {
 		return nil, fmt.Errorf("creating directory source: %w", err)
 	}
@@ -151,25 +425,3 @@ func getResolver(path string) (file.Resolver, error) {
 
 	return resolver, nil
 }
-
-// getProjectName
-func getProjectName(path string) (string, error) {
-	r, err := git.OpenRepo(path)
-	if err != nil {
-		return "", err
-	}
-	remotes, err := r.Remotes()
-	if err != nil {
-		return "", err
-	}
-
-	for _, r := range remotes {
-		if r.Name() == "origin" {
-			if len(r.URLs()) > 0 {
-				return r.URLs()[0], nil
-			}
-		}
-	}
-
-	return "", nil
-}
@@ -0,0 +1,82 @@
+package packages
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/anchore/syft/syft/file"
+	"github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/pkg/cataloger/generic"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// parsedPackage captures the data the parsers record about a package
+type parsedPackage struct {
+	Direct       bool
+	Scope        trusty.Scope
+	Alias        string
+	EdgesUnknown bool
+}
+
+func parsedMetadata(p *pkg.Package) parsedPackage {
+	meta := packageMetadata(p)
+	if meta.Scope == "" {
+		meta.Scope = trusty.ScopeRuntime
+	}
+	return parsedPackage{Direct: meta.Direct, Scope: meta.Scope, Alias: meta.Alias, EdgesUnknown: meta.EdgesUnknown}
+}
+
+// fixtureResolver returns a resolver over all the files under dir
+func fixtureResolver(t *testing.T, dir string) file.Resolver {
+	t.Helper()
+	paths := []string{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			paths = append(paths, filepath.ToSlash(p))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file.NewMockResolverForPaths(paths...)
+}
+
+// parseFixture runs a parser on the manifest of the fixture in dir, with a
+// resolver over all the files of the fixture. It returns the packages found
+// and their edges formatted as "parent@version -> dependency@version (scope)".
+func parseFixture(t *testing.T, parser generic.Parser, dir, manifest string) ([]pkg.Package, []string) {
+	t.Helper()
+	manifestPath := filepath.ToSlash(filepath.Join(dir, manifest))
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pkgs, rels, err := parser(
+		context.Background(), fixtureResolver(t, dir), nil,
+		file.NewLocationReadCloser(file.NewLocation(manifestPath), f),
+	)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", manifestPath, err)
+	}
+
+	edges := []string{}
+	for i := range rels {
+		r := &rels[i]
+		parent, dep := r.To.(pkg.Package), r.From.(pkg.Package)
+		edges = append(edges, fmt.Sprintf(
+			"%s@%s -> %s@%s (%s)",
+			parent.Name, parent.Version, dep.Name, dep.Version, relationshipScope(r),
+		))
+	}
+	sort.Strings(edges)
+	return pkgs, edges
+}
@@ -0,0 +1,436 @@
+package packages
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// Project is the identity of the project being attested, read from its
+// manifest and the git repository it lives in.
+type Project struct {
+	Name      string
+	Version   string
+	Ecosystem Ecosystem
+	Purl      string
+
+	// Repository is the URL of the origin remote
+	Repository string
+
+	// Commit is the SHA of the git HEAD and Tag the nearest tag
+	// reachable from it
+	Commit string
+	Tag    string
+}
+
+// IdentifyProject reads the project identity from the manifest at the top
+// of path and its git repository. It never fails: when the directory is not
+// a git repository or has no manifest the missing data is left blank and
+// the name falls back to the origin URL or the directory name.
+func IdentifyProject(path string) *Project {
+	rev, err := openRevision(path, "")
+	if err != nil {
+		logrus.Debugf("%s is not in a git repository: %v", path, err)
+		rev = nil
+	}
+	return identifyProject(rev, dirReader(path), path)
+}
+
+// identifyProject builds the project identity from the git revision (which
+// can be nil) and the manifest read with readFile.
+func identifyProject(rev *gitRevision, readFile func(string) ([]byte, error), path string) *Project {
+	p := &Project{}
+	if rev != nil {
+		p.Repository = rev.repository
+		p.Commit = rev.commit.Hash.String()
+		p.Tag = rev.tag
+	}
+
+	readManifestIdentity(readFile, p)
+
+	// Go modules are not versioned in the manifest, the version is the
+	// tag at the commit or a pseudo-version derived from the nearest tag.
+	if p.Ecosystem == Go && rev != nil {
+		tag, tagged := rev.goModuleTag(p.Name)
+		p.Version = goModuleVersion(tag, tagged, rev.commit)
+	}
+
+	if p.Version == "" && rev != nil && rev.tagged {
+		p.Version = rev.tag
+	}
+
+	if p.Name != "" {
+		p.Purl = projectPurl(p.Ecosystem, p.Name, p.Version)
+	}
+
+	if p.Name == "" {
+		p.Name = p.Repository
+	}
+	if p.Name == "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			p.Name = filepath.Base(abs)
+		}
+	}
+	return p
+}
+
+// dirReader returns a function that reads files from dir
+func dirReader(dir string) func(string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+}
+
+// readManifestIdentity reads the project name and version from the first
+// manifest found.
+func readManifestIdentity(readFile func(string) ([]byte, error), p *Project) {
+	if data, err := readFile("go.mod"); err == nil {
+		if mod, err := modfile.ParseLax("go.mod", data, nil); err == nil && mod.Module != nil {
+			p.Name = mod.Module.Mod.Path
+			p.Ecosystem = Go
+			return
+		}
+	}
+
+	if data, err := readFile("package.json"); err == nil {
+		manifest := struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}{}
+		if err := json.Unmarshal(data, &manifest); err == nil && manifest.Name != "" {
+			p.Name = manifest.Name
+			p.Version = manifest.Version
+			p.Ecosystem = Npm
+			return
+		}
+	}
+
+	if data, err := readFile("pyproject.toml"); err == nil {
+		manifest := struct {
+			Project struct {
+				Name    string `toml:"name"`
+				Version string `toml:"version"`
+			} `toml:"project"`
+			Tool struct {
+				Poetry struct {
+					Name    string `toml:"name"`
+					Version string `toml:"version"`
+				} `toml:"poetry"`
+			} `toml:"tool"`
+		}{}
+		if err := toml.Unmarshal(data, &manifest); err == nil {
+			name, version := manifest.Project.Name, manifest.Project.Version
+			if name == "" {
+				name, version = manifest.Tool.Poetry.Name, manifest.Tool.Poetry.Version
+			}
+			if name != "" {
+				p.Name = name
+				p.Version = version
+				p.Ecosystem = Python
+			}
+		}
+	}
+}
+
+// gitRevision is a commit of the git repository where the project lives
+type gitRevision struct {
+	repo       *git.Repository
+	repository string
+	commit     *object.Commit
+
+	// tag is the nearest tag to the commit, tagged is true if it
+	// points to the commit itself
+	tag    string
+	tagged bool
+
+	// tree is the tree of the project directory at the commit
+	tree *object.Tree
+
+	// path is the project directory relative to the repository root,
+	// blank if the repository has no worktree
+	path string
+}
+
+// openRevision opens the git repository containing path and resolves rev.
+// If rev is blank, HEAD is used.
+func openRevision(path, rev string) (*gitRevision, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("opening git repository: %w", err)
+	}
+
+	r := &gitRevision{repo: repo}
+	if remote, err := repo.Remote("origin"); err == nil && len(remote.Config().URLs) > 0 {
+		r.repository = remote.Config().URLs[0]
+	}
+
+	var hash *plumbing.Hash
+	if rev == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, fmt.Errorf("reading git HEAD: %w", err)
+		}
+		h := head.Hash()
+		hash = &h
+	} else {
+		hash, err = repo.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return nil, fmt.Errorf("resolving revision %q: %w", rev, err)
+		}
+	}
+
+	r.commit, err = repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("reading commit %s: %w", hash, err)
+	}
+
+	r.tag, r.tagged, err = nearestTag(repo, r.commit, nil)
+	if err != nil {
+		logrus.Debugf("looking for the nearest tag: %v", err)
+	}
+
+	r.tree, err = r.commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("reading tree of %s: %w", hash, err)
+	}
+
+	// If path is a subdirectory of the repository, descend to its tree
+	wt, err := repo.Worktree()
+	if err != nil {
+		return r, nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving path: %w", err)
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil {
+		return nil, fmt.Errorf("computing path in repository: %w", err)
+	}
+	r.path = filepath.ToSlash(rel)
+	if rel != "." {
+		r.tree, err = r.tree.Tree(filepath.ToSlash(rel))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s at %s: %w", rel, hash, err)
+		}
+	}
+	return r, nil
+}
+
+// RepoPath returns path relative to the root of the git repository it
+// lives in, so it reads the same in every checkout. Paths outside of a
+// repository are reduced to their base name.
+func RepoPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	dir := abs
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		dir = filepath.Dir(abs)
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return filepath.Base(abs)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return filepath.Base(abs)
+	}
+	rel, err := filepath.Rel(wt.Filesystem.Root(), abs)
+	if err != nil {
+		return filepath.Base(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readFile reads a file from the revision, name is relative to the
+// project directory.
+func (r *gitRevision) readFile(name string) ([]byte, error) {
+	f, err := r.tree.File(name)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(contents), nil
+}
+
+// export writes the files of the revision that pass the keep function to
+// dir, preserving their paths.
+func (r *gitRevision) export(dir string, keep func(string) bool) error {
+	return r.tree.Files().ForEach(func(f *object.File) error {
+		if !keep(f.Name) {
+			return nil
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(dest), os.FileMode(0o755)); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		if err := os.WriteFile(dest, []byte(contents), os.FileMode(0o644)); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Name, err)
+		}
+		return nil
+	})
+}
+
+// tagFilter selects the tags considered by nearestTag and returns the
+// version they name
+type tagFilter func(name string) (string, bool)
+
+// nearestTag returns the tag closest to commit, counting the commits
+// walked back through its parents, and true if the tag points to the commit
+// itself. Like git describe, the history is searched breadth first so a
+// tag on a merged branch is not shadowed by an older one on the first
+// parent line. Tags at the same distance are ordered by semver. It returns
+// a blank string if there are no tags. When filter is set, only the tags
+// it selects are considered and the version it returns is used instead.
+func nearestTag(repo *git.Repository, commit *object.Commit, filter tagFilter) (string, bool, error) {
+	tags, err := tagsByCommit(repo, filter)
+	if err != nil || len(tags) == 0 {
+		return "", false, err
+	}
+
+	seen := map[plumbing.Hash]struct{}{commit.Hash: {}}
+	level := []*object.Commit{commit}
+	for len(level) > 0 {
+		tag := ""
+		for _, c := range level {
+			if t, ok := tags[c.Hash]; ok && (tag == "" || semver.Compare(t, tag) > 0) {
+				tag = t
+			}
+		}
+		if tag != "" {
+			return tag, tags[commit.Hash] == tag, nil
+		}
+
+		next := []*object.Commit{}
+		for _, c := range level {
+			err := c.Parents().ForEach(func(p *object.Commit) error {
+				if _, ok := seen[p.Hash]; !ok {
+					seen[p.Hash] = struct{}{}
+					next = append(next, p)
+				}
+				return nil
+			})
+			if err != nil {
+				return "", false, fmt.Errorf("reading parents of %s: %w", c.Hash, err)
+			}
+		}
+		level = next
+	}
+	return "", false, nil
+}
+
+// tagsByCommit indexes the repository tags by the commit they point to,
+// peeling annotated tags. When a commit has more than one tag, the highest
+// semver wins. Tags not selected by filter, if set, are skipped.
+func tagsByCommit(repo *git.Repository, filter tagFilter) (map[plumbing.Hash]string, error) {
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	ret := map[plumbing.Hash]string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		if tagObject, err := repo.TagObject(hash); err == nil {
+			commit, err := tagObject.Commit()
+			if err != nil {
+				return nil
+			}
+			hash = commit.Hash
+		}
+		name := ref.Name().Short()
+		if filter != nil {
+			var ok bool
+			if name, ok = filter(name); !ok {
+				return nil
+			}
+		}
+		if existing, ok := ret[hash]; ok && semver.Compare(existing, name) >= 0 {
+			return nil
+		}
+		ret[hash] = name
+		return nil
+	})
+	return ret, err
+}
+
+// goModuleTag returns the nearest tag versioning the Go module modPath in
+// the project directory, with the directory prefix stripped. Nested modules
+// are tagged with their directory ("tools/v1.2.0"), except for the major
+// version subdirectory of the module path ("v2/" holds module/v2, tagged
+// "v2.0.0"). Tags of other modules are skipped.
+func (r *gitRevision) goModuleTag(modPath string) (string, bool) {
+	dir := r.path
+	if _, major, ok := module.SplitPathVersion(modPath); ok && major != "" && path.Base(dir) == strings.TrimPrefix(major, "/") {
+		dir = path.Dir(dir)
+	}
+	prefix := ""
+	if dir != "" && dir != "." {
+		prefix = dir + "/"
+	}
+	tag, tagged, err := nearestTag(r.repo, r.commit, func(name string) (string, bool) {
+		version, ok := strings.CutPrefix(name, prefix)
+		return version, ok && semver.IsValid(version)
+	})
+	if err != nil {
+		logrus.Debugf("looking for the nearest tag of %s: %v", modPath, err)
+	}
+	return tag, tagged
+}
+
+// goModuleVersion returns the version of a Go module at commit: the tag
+// if it points to the commit, otherwise a pseudo-version.
+func goModuleVersion(tag string, tagged bool, commit *object.Commit) string {
+	if tagged && semver.IsValid(tag) {
+		return tag
+	}
+	older := ""
+	if semver.IsValid(tag) {
+		older = tag
+	}
+	return module.PseudoVersion(semver.Major(older), older, commit.Committer.When, commit.Hash.String()[:12])
+}
+
+// projectPurl returns the package URL of the project
+func projectPurl(ecosystem Ecosystem, name, version string) string {
+	namespace := ""
+	var purlType string
+	switch ecosystem {
+	case Go:
+		purlType = packageurl.TypeGolang
+		if i := strings.LastIndex(name, "/"); i != -1 {
+			namespace, name = name[:i], name[i+1:]
+		}
+	case Npm:
+		purlType = packageurl.TypeNPM
+		if strings.HasPrefix(name, "@") && strings.Contains(name, "/") {
+			namespace, name, _ = strings.Cut(name, "/")
+		}
+	case Python:
+		purlType = packageurl.TypePyPi
+	default:
+		return ""
+	}
+	return packageurl.NewPackageURL(purlType, namespace, name, version, nil, "").ToString()
+}
@@ -0,0 +1,138 @@
+package packages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// testRepo builds git histories in memory
+type testRepo struct {
+	t    *testing.T
+	repo *git.Repository
+	wt   *git.Worktree
+	when time.Time
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{t: t, repo: repo, wt: wt, when: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// commit creates an empty commit with the parents, tagged with tags
+func (r *testRepo) commit(msg string, parents []plumbing.Hash, tags ...string) plumbing.Hash {
+	r.t.Helper()
+	r.when = r.when.Add(time.Hour)
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: r.when}
+	hash, err := r.wt.Commit(msg, &git.CommitOptions{
+		AllowEmptyCommits: true, Author: sig, Committer: sig, Parents: parents,
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	for _, tag := range tags {
+		if _, err := r.repo.CreateTag(tag, hash, nil); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	return hash
+}
+
+func TestNearestTag(t *testing.T) {
+	// The first parent line of the merge reaches v1.0.0 after four
+	// commits, the merged branch was tagged v1.1.0 right before the merge:
+	//
+	//	root (v1.0.0) - a - b - c - merge
+	//	     \                     /
+	//	      feature (v1.1.0) ----
+	r := newTestRepo(t)
+	root := r.commit("root", nil, "v1.0.0")
+	a := r.commit("a", []plumbing.Hash{root})
+	b := r.commit("b", []plumbing.Hash{a})
+	c := r.commit("c", []plumbing.Hash{b})
+	feature := r.commit("feature", []plumbing.Hash{root}, "v1.1.0")
+	merge := r.commit("merge", []plumbing.Hash{c, feature})
+	fix := r.commit("fix", []plumbing.Hash{merge}, "v1.1.1-rc.1", "v1.1.1")
+	untagged := newTestRepo(t)
+	lonely := untagged.commit("lonely", nil)
+
+	for _, tc := range []struct {
+		name   string
+		repo   *testRepo
+		commit plumbing.Hash
+		tag    string
+		tagged bool
+	}{
+		{"merge", r, merge, "v1.1.0", false},
+		{"first-parent", r, c, "v1.0.0", false},
+		{"tagged", r, feature, "v1.1.0", true},
+		{"highest-semver", r, fix, "v1.1.1", true},
+		{"no-tags", untagged, lonely, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			commit, err := tc.repo.repo.CommitObject(tc.commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag, tagged, err := nearestTag(tc.repo.repo, commit, nil)
+			if err != nil {
+				t.Fatalf("nearestTag: %v", err)
+			}
+			if tag != tc.tag || tagged != tc.tagged {
+				t.Errorf("expected %q (tagged %v), got %q (tagged %v)", tc.tag, tc.tagged, tag, tagged)
+			}
+		})
+	}
+}
+
+func TestGoModuleTag(t *testing.T) {
+	// The root module and the one in tools are tagged on the same line:
+	//
+	//	root (v1.0.0) - a (tools/v0.3.0) - b (v1.1.0) - c (tools/v0.4.0)
+	r := newTestRepo(t)
+	root := r.commit("root", nil, "v1.0.0")
+	a := r.commit("a", []plumbing.Hash{root}, "tools/v0.3.0")
+	b := r.commit("b", []plumbing.Hash{a}, "v1.1.0")
+	c := r.commit("c", []plumbing.Hash{b}, "tools/v0.4.0")
+
+	for _, tc := range []struct {
+		name    string
+		commit  plumbing.Hash
+		dir     string
+		modPath string
+		tag     string
+		tagged  bool
+	}{
+		{"root", c, ".", "example.com/m", "v1.1.0", false},
+		{"root-tagged", b, ".", "example.com/m", "v1.1.0", true},
+		{"nested", c, "tools", "example.com/m/tools", "v0.4.0", true},
+		{"nested-older", b, "tools", "example.com/m/tools", "v0.3.0", false},
+		{"major-dir", c, "v2", "example.com/m/v2", "v1.1.0", false},
+		{"untagged", c, "other", "example.com/m/other", "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			commit, err := r.repo.CommitObject(tc.commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rev := &gitRevision{repo: r.repo, commit: commit, path: tc.dir}
+			tag, tagged := rev.goModuleTag(tc.modPath)
+			if tag != tc.tag || tagged != tc.tagged {
+				t.Errorf("expected %q (tagged %v), got %q (tagged %v)", tc.tag, tc.tagged, tag, tagged)
+			}
+		})
+	}
+}
@@ -0,0 +1,596 @@
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+	"github.com/anchore/syft/syft/artifact"
+	"github.com/anchore/syft/syft/file"
+	"github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/pkg/cataloger/generic"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// pythonLockFiles are the lockfiles that pin the dependencies declared
+// in pyproject.toml
+var pythonLockFiles = []string{"poetry.lock", "uv.lock", "Pipfile.lock"}
+
+// pythonCatalogers returns the catalogers that read the dependencies
+// declared in python source trees.
+func pythonCatalogers() []pkg.Cataloger {
+	return []pkg.Cataloger{
+		generic.NewCataloger("python-requirements-cataloger").
+			WithParserByGlobs(parseRequirements, "**/*requirements*.txt").
+			WithParserByGlobs(parseSetup, "**/setup.py"),
+		generic.NewCataloger("python-poetry-lock-cataloger").
+			WithParserByGlobs(parsePoetryLock, "**/poetry.lock"),
+		generic.NewCataloger("python-pipfile-lock-cataloger").
+			WithParserByGlobs(parsePipfileLock, "**/Pipfile.lock"),
+		generic.NewCataloger("python-uv-lock-cataloger").
+			WithParserByGlobs(parseUvLock, "**/uv.lock"),
+		generic.NewCataloger("python-pyproject-cataloger").
+			WithParserByGlobs(parsePyproject, "**/pyproject.toml"),
+	}
+}
+
+// uvLock captures the parts of a uv.lock file we care about
+type uvLock struct {
+	Packages []struct {
+		Name                 string                    `toml:"name"`
+		Version              string                    `toml:"version"`
+		Source               map[string]any            `toml:"source"`
+		Dependencies         []uvDependency            `toml:"dependencies"`
+		OptionalDependencies map[string][]uvDependency `toml:"optional-dependencies"`
+		DevDependencies      map[string][]uvDependency `toml:"dev-dependencies"`
+	} `toml:"package"`
+}
+
+// uvDependency is a reference to another package in uv.lock. The version
+// is only recorded when the lockfile has more than one of the package.
+type uvDependency struct {
+	Name    string `toml:"name"`
+	Version string `toml:"version"`
+}
+
+// parseUvLock reads the packages pinned in a uv.lock file and their
+// dependencies. Packages sourced from the tree itself (the project and its
+// workspace members) are skipped, their dependencies are the direct ones.
+func parseUvLock(_ context.Context, _ file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	lock := uvLock{}
+	if err := toml.NewDecoder(reader).Decode(&lock); err != nil {
+		return nil, nil, fmt.Errorf("parsing uv.lock: %w", err)
+	}
+
+	isProject := func(source map[string]any) bool {
+		_, editable := source["editable"]
+		_, virtual := source["virtual"]
+		return editable || virtual
+	}
+
+	// The dependencies of a package by scope. Extras are optional and
+	// dependency groups are development dependencies.
+	declared := func(i int) []uvScopedDeps {
+		ret := []uvScopedDeps{{trusty.ScopeRuntime, lock.Packages[i].Dependencies}}
+		for _, d := range lock.Packages[i].OptionalDependencies {
+			ret = append(ret, uvScopedDeps{trusty.ScopeOptional, d})
+		}
+		for _, d := range lock.Packages[i].DevDependencies {
+			ret = append(ret, uvScopedDeps{trusty.ScopeDev, d})
+		}
+		return ret
+	}
+
+	// Index the versions required by the projects and their scope, a
+	// blank version means the lockfile has a single version of the package.
+	direct := map[string]map[string]trusty.Scope{}
+	for i, p := range lock.Packages {
+		if !isProject(p.Source) {
+			continue
+		}
+		for _, deps := range declared(i) {
+			for _, d := range deps.Deps {
+				name := normalizePythonName(d.Name)
+				if _, ok := direct[name]; !ok {
+					direct[name] = map[string]trusty.Scope{}
+				}
+				addScope(direct[name], d.Version, deps.Scope)
+			}
+		}
+	}
+
+	pkgs := []pkg.Package{}
+	byName := map[string]pkg.Package{}
+	byVersion := map[string]pkg.Package{}
+	for _, p := range lock.Packages {
+		if isProject(p.Source) {
+			continue
+		}
+		name := normalizePythonName(p.Name)
+		meta := dependencyMetadata{}
+		for _, v := range []string{"", p.Version} {
+			if scope, ok := direct[name][v]; ok && (!meta.Direct || scope.Rank() < meta.Scope.Rank()) {
+				meta.Direct = true
+				meta.Scope = scope
+			}
+		}
+		np := newPythonPackage(p.Name, p.Version, meta, reader.Location)
+		if _, ok := byName[name]; !ok {
+			byName[name] = np
+		}
+		byVersion[name+"@"+p.Version] = np
+		pkgs = append(pkgs, np)
+	}
+
+	rels := []artifact.Relationship{}
+	for i, p := range lock.Packages {
+		parent, ok := byVersion[normalizePythonName(p.Name)+"@"+p.Version]
+		if !ok {
+			continue
+		}
+		for _, deps := range declared(i) {
+			for _, d := range deps.Deps {
+				dep, ok := byVersion[normalizePythonName(d.Name)+"@"+d.Version]
+				if !ok {
+					dep, ok = byName[normalizePythonName(d.Name)]
+				}
+				if ok {
+					rels = append(rels, dependencyOf(dep, parent, deps.Scope))
+				}
+			}
+		}
+	}
+	return pkgs, rels, nil
+}
+
+// uvScopedDeps is a list of dependencies in uv.lock with their scope
+type uvScopedDeps struct {
+	Scope trusty.Scope
+	Deps  []uvDependency
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName returns the PEP 503 normalized form of a name
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameSeparators.ReplaceAllString(name, "-"))
+}
+
+// pyproject captures the dependency declarations of a pyproject.toml file
+type pyproject struct {
+	Project struct {
+		Dependencies         []string            `toml:"dependencies"`
+		OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+	} `toml:"project"`
+	DependencyGroups map[string][]any `toml:"dependency-groups"`
+	Tool             struct {
+		Poetry struct {
+			Dependencies    map[string]any `toml:"dependencies"`
+			DevDependencies map[string]any `toml:"dev-dependencies"`
+			Group           map[string]struct {
+				Dependencies map[string]any `toml:"dependencies"`
+			} `toml:"group"`
+		} `toml:"poetry"`
+	} `toml:"tool"`
+}
+
+// pythonRequirement is a dependency declared by a python project
+type pythonRequirement struct {
+	Name string
+	// Version is the exact version the requirement is pinned to, blank
+	// when it is not pinned.
+	Version string
+	// Spec is the requirement as declared, to report it
+	Spec  string
+	Scope trusty.Scope
+}
+
+// requirements returns the dependencies declared in pyproject.toml. Extras
+// are optional dependencies, dependency groups (PEP 735) and poetry groups
+// are development dependencies.
+func (p *pyproject) requirements() []pythonRequirement {
+	ret := []pythonRequirement{}
+	add := func(req string, scope trusty.Scope) {
+		name, version, _ := pinnedRequirement(req)
+		ret = append(ret, pythonRequirement{Name: name, Version: version, Spec: req, Scope: scope})
+	}
+	for _, r := range p.Project.Dependencies {
+		add(r, trusty.ScopeRuntime)
+	}
+	for _, deps := range p.Project.OptionalDependencies {
+		for _, r := range deps {
+			add(r, trusty.ScopeOptional)
+		}
+	}
+	for _, deps := range p.DependencyGroups {
+		for _, r := range deps {
+			// Groups can also include other groups as tables
+			if req, ok := r.(string); ok {
+				add(req, trusty.ScopeDev)
+			}
+		}
+	}
+
+	// Poetry declares dependencies as tables, the main one and the legacy
+	// dev-dependencies or groups which are development dependencies.
+	poetryDeps := []poetryScopedDeps{
+		{trusty.ScopeRuntime, p.Tool.Poetry.Dependencies},
+		{trusty.ScopeDev, p.Tool.Poetry.DevDependencies},
+	}
+	for _, g := range p.Tool.Poetry.Group {
+		poetryDeps = append(poetryDeps, poetryScopedDeps{trusty.ScopeDev, g.Dependencies})
+	}
+
+	// In poetry, a bare version is an exact pin
+	for _, deps := range poetryDeps {
+		for name, spec := range deps.Deps {
+			if strings.EqualFold(name, "python") {
+				continue
+			}
+			scope := deps.Scope
+			version := ""
+			switch s := spec.(type) {
+			case string:
+				version = s
+			case map[string]any:
+				if v, ok := s["version"].(string); ok {
+					version = v
+				}
+				if optional, ok := s["optional"].(bool); ok && optional && scope == trusty.ScopeRuntime {
+					scope = trusty.ScopeOptional
+				}
+			}
+			version = strings.TrimPrefix(strings.TrimSpace(version), "==")
+			if !exactVersion.MatchString(version) {
+				version = ""
+			}
+			ret = append(ret, pythonRequirement{
+				Name: name, Version: version, Spec: fmt.Sprintf("%s %v", name, spec), Scope: scope,
+			})
+		}
+	}
+	return ret
+}
+
+// poetryScopedDeps is a poetry dependency table with its scope
+type poetryScopedDeps struct {
+	Scope trusty.Scope
+	Deps  map[string]any
+}
+
+// parsePyproject reads the dependencies declared in pyproject.toml. If the
+// project has a lockfile, it is left to the lockfile parsers. Otherwise
+// only exactly pinned requirements are cataloged, the rest are reported.
+func parsePyproject(_ context.Context, resolver file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	dir := path.Dir(reader.RealPath)
+	for _, lf := range pythonLockFiles {
+		locations, err := resolver.FilesByPath(path.Join(dir, lf))
+		if err == nil && len(locations) > 0 {
+			return nil, nil, nil
+		}
+	}
+
+	proj := pyproject{}
+	if err := toml.NewDecoder(reader).Decode(&proj); err != nil {
+		return nil, nil, fmt.Errorf("parsing pyproject.toml: %w", err)
+	}
+
+	// A requirement declared in several scopes is cataloged once, with
+	// the most critical of them.
+	pinned := map[pythonRequirement]trusty.Scope{}
+	order := []pythonRequirement{}
+	unpinned := map[string]struct{}{}
+	for _, r := range proj.requirements() {
+		if r.Version == "" {
+			if _, ok := unpinned[r.Spec]; !ok {
+				reportUnpinned(reader.RealPath, r.Spec)
+			}
+			unpinned[r.Spec] = struct{}{}
+			continue
+		}
+		key := pythonRequirement{Name: r.Name, Version: r.Version}
+		if _, ok := pinned[key]; !ok {
+			order = append(order, key)
+		}
+		addScope(pinned, key, r.Scope)
+	}
+
+	pkgs := []pkg.Package{}
+	for _, r := range order {
+		pkgs = append(pkgs, newPythonPackage(r.Name, r.Version, dependencyMetadata{Direct: true, Scope: pinned[r]}, reader.Location))
+	}
+	return pkgs, nil, nil
+}
+
+// readSibling decodes the TOML file name in the directory of the file at
+// location into v. It returns false when the file does not exist.
+func readSibling(resolver file.Resolver, location file.Location, name string, v any) (bool, error) {
+	locations, err := resolver.FilesByPath(path.Join(path.Dir(location.RealPath), name))
+	if err != nil || len(locations) == 0 {
+		return false, nil
+	}
+	rc, err := resolver.FileContentsByLocation(locations[0])
+	if err != nil {
+		return false, fmt.Errorf("opening %s: %w", name, err)
+	}
+	defer rc.Close()
+	if err := toml.NewDecoder(rc).Decode(v); err != nil {
+		return false, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// pythonDirectScopes indexes the scopes in which the project requires each
+// package by normalized name
+func pythonDirectScopes(reqs []pythonRequirement) map[string]trusty.Scope {
+	ret := map[string]trusty.Scope{}
+	for _, r := range reqs {
+		addScope(ret, normalizePythonName(r.Name), r.Scope)
+	}
+	return ret
+}
+
+// poetryLock captures the parts of a poetry.lock file we care about
+type poetryLock struct {
+	Packages []struct {
+		Name    string `toml:"name"`
+		Version string `toml:"version"`
+		// Category ("main" or "dev") is written by poetry < 1.5, newer
+		// versions record the groups that require the package.
+		Category     string         `toml:"category"`
+		Groups       []string       `toml:"groups"`
+		Optional     bool           `toml:"optional"`
+		Dependencies map[string]any `toml:"dependencies"`
+	} `toml:"package"`
+}
+
+// parsePoetryLock reads the packages pinned in a poetry.lock file and
+// their dependencies. The direct dependencies and their scopes are read
+// from the pyproject.toml next to it. Packages flagged in the lockfile as
+// only required by development groups or extras keep that scope.
+func parsePoetryLock(_ context.Context, resolver file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	lock := poetryLock{}
+	if err := toml.NewDecoder(reader).Decode(&lock); err != nil {
+		return nil, nil, fmt.Errorf("parsing poetry.lock: %w", err)
+	}
+
+	proj := pyproject{}
+	if _, err := readSibling(resolver, reader.Location, "pyproject.toml", &proj); err != nil {
+		return nil, nil, err
+	}
+	direct := pythonDirectScopes(proj.requirements())
+
+	pkgs := []pkg.Package{}
+	byName := map[string]pkg.Package{}
+	for _, p := range lock.Packages {
+		meta := dependencyMetadata{Scope: poetryFlagScope(p.Category, p.Groups, p.Optional)}
+		if scope, ok := direct[normalizePythonName(p.Name)]; ok {
+			meta = dependencyMetadata{Direct: true, Scope: scope}
+		}
+		np := newPythonPackage(p.Name, p.Version, meta, reader.Location)
+		if _, ok := byName[normalizePythonName(p.Name)]; !ok {
+			byName[normalizePythonName(p.Name)] = np
+		}
+		pkgs = append(pkgs, np)
+	}
+
+	rels := []artifact.Relationship{}
+	for i, p := range lock.Packages {
+		for name, spec := range p.Dependencies {
+			dep, ok := byName[normalizePythonName(name)]
+			if !ok {
+				continue
+			}
+			scope := trusty.ScopeRuntime
+			if s, ok := spec.(map[string]any); ok {
+				if optional, ok := s["optional"].(bool); ok && optional {
+					scope = trusty.ScopeOptional
+				}
+			}
+			rels = append(rels, dependencyOf(dep, pkgs[i], scope))
+		}
+	}
+	return pkgs, rels, nil
+}
+
+// poetryFlagScope returns the scope of a package from its poetry.lock flags
+func poetryFlagScope(category string, groups []string, optional bool) trusty.Scope {
+	switch {
+	case optional:
+		return trusty.ScopeOptional
+	case category == "dev":
+		return trusty.ScopeDev
+	case len(groups) > 0 && !slices.Contains(groups, "main"):
+		return trusty.ScopeDev
+	}
+	return trusty.ScopeRuntime
+}
+
+// pipfileLock captures the parts of a Pipfile.lock file we care about
+type pipfileLock struct {
+	Default map[string]pipfileLockEntry `json:"default"`
+	Develop map[string]pipfileLockEntry `json:"develop"`
+}
+
+// pipfileLockEntry is a package pinned in Pipfile.lock. Packages installed
+// from a path or VCS have no version.
+type pipfileLockEntry struct {
+	Version string `json:"version"`
+}
+
+// pipfile captures the dependency declarations of a Pipfile
+type pipfile struct {
+	Packages    map[string]any `toml:"packages"`
+	DevPackages map[string]any `toml:"dev-packages"`
+}
+
+// parsePipfileLock reads the packages pinned in a Pipfile.lock file, those
+// in the develop section are development dependencies. The lockfile does
+// not record the dependencies among the packages, the direct dependencies
+// are read from the Pipfile next to it.
+func parsePipfileLock(_ context.Context, resolver file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	lock := pipfileLock{}
+	if err := json.NewDecoder(reader).Decode(&lock); err != nil {
+		return nil, nil, fmt.Errorf("parsing Pipfile.lock: %w", err)
+	}
+
+	manifest := pipfile{}
+	if _, err := readSibling(resolver, reader.Location, "Pipfile", &manifest); err != nil {
+		return nil, nil, err
+	}
+	direct := map[string]struct{}{}
+	for _, deps := range []map[string]any{manifest.Packages, manifest.DevPackages} {
+		for name := range deps {
+			direct[normalizePythonName(name)] = struct{}{}
+		}
+	}
+
+	// Packages in both sections are cataloged once, as runtime ones
+	scopes := map[pythonRequirement]trusty.Scope{}
+	for _, section := range []struct {
+		scope   trusty.Scope
+		entries map[string]pipfileLockEntry
+	}{
+		{trusty.ScopeRuntime, lock.Default},
+		{trusty.ScopeDev, lock.Develop},
+	} {
+		for name, e := range section.entries {
+			version := strings.TrimPrefix(e.Version, "==")
+			if version == "" {
+				continue
+			}
+			addScope(scopes, pythonRequirement{Name: name, Version: version}, section.scope)
+		}
+	}
+
+	keys := make([]pythonRequirement, 0, len(scopes))
+	for k := range scopes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name+"@"+keys[i].Version < keys[j].Name+"@"+keys[j].Version
+	})
+
+	pkgs := []pkg.Package{}
+	for _, k := range keys {
+		_, isDirect := direct[normalizePythonName(k.Name)]
+		meta := dependencyMetadata{Direct: isDirect, Scope: scopes[k]}
+		pkgs = append(pkgs, newPythonPackage(k.Name, k.Version, meta, reader.Location))
+	}
+	return pkgs, nil, nil
+}
+
+// parseRequirements reads the requirements pinned to an exact version in
+// a requirements file and reports those that are not pinned.
+func parseRequirements(_ context.Context, _ file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading requirements file: %w", err)
+	}
+
+	pkgs := []pkg.Package{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(strings.SplitN(line, "#", 2)[0])
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+		// Per requirement options (eg --hash) follow the requirement
+		if i := strings.Index(line, " --"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		// Skip blank lines, pip options and references to other files or URLs
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		name, version, ok := pinnedRequirement(line)
+		if !ok {
+			reportUnpinned(reader.RealPath, line)
+			continue
+		}
+		pkgs = append(pkgs, newPythonPackage(name, version, dependencyMetadata{}, reader.Location))
+	}
+	return pkgs, nil, nil
+}
+
+// setupRequirement matches the quoted requirements in setup.py files
+var setupRequirement = regexp.MustCompile(`['"]([^'"]+)['"]`)
+
+// parseSetup reads the requirements pinned to an exact version in setup.py
+// files. The file is not evaluated, requirements built dynamically are
+// missed.
+func parseSetup(_ context.Context, _ file.Resolver, _ *generic.Environment, reader file.LocationReadCloser) ([]pkg.Package, []artifact.Relationship, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading setup.py: %w", err)
+	}
+
+	pkgs := []pkg.Package{}
+	for _, m := range setupRequirement.FindAllStringSubmatch(string(data), -1) {
+		if !strings.Contains(m[1], "==") {
+			continue
+		}
+		if name, version, ok := pinnedRequirement(m[1]); ok {
+			pkgs = append(pkgs, newPythonPackage(name, version, dependencyMetadata{}, reader.Location))
+		}
+	}
+	return pkgs, nil, nil
+}
+
+var (
+	// requirementRegex splits a PEP 508 requirement in name and version spec
+	requirementRegex = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*\(?([^;]*?)\)?\s*(;.*)?$`)
+	exactVersion     = regexp.MustCompile(`^[0-9][0-9A-Za-z.+!_-]*$`)
+)
+
+// pinnedRequirement parses a requirement string and returns the package
+// name and version if it is pinned to an exact version (== or ===).
+func pinnedRequirement(req string) (name, version string, ok bool) {
+	m := requirementRegex.FindStringSubmatch(req)
+	if m == nil {
+		return "", "", false
+	}
+	spec := strings.TrimSpace(m[3])
+	switch {
+	case strings.HasPrefix(spec, "==="):
+		version = strings.TrimSpace(strings.TrimPrefix(spec, "==="))
+	case strings.HasPrefix(spec, "=="):
+		version = strings.TrimSpace(strings.TrimPrefix(spec, "=="))
+	default:
+		return m[1], "", false
+	}
+	if !exactVersion.MatchString(version) {
+		return m[1], "", false
+	}
+	return m[1], version, true
+}
+
+// reportUnpinned logs a requirement that is not included in the package
+// list because it is not pinned to a version.
+func reportUnpinned(path, req string) {
+	logrus.Warnf("%s: skipping unpinned requirement %q", path, strings.TrimSpace(req))
+}
+
+// newPythonPackage returns a python package found at location
+func newPythonPackage(name, version string, meta dependencyMetadata, location file.Location) pkg.Package {
+	p := pkg.Package{
+		Name:      name,
+		Version:   version,
+		Locations: file.NewLocationSet(location),
+		PURL: packageurl.NewPackageURL(
+			packageurl.TypePyPi, "", name, version, nil, "",
+		).ToString(),
+		Language: pkg.Python,
+		Type:     pkg.PythonPkg,
+		Metadata: meta,
+	}
+	p.SetID()
+	return p
+}
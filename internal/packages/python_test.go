@@ -0,0 +1,208 @@
+package packages
+
+import (
+	"maps"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/anchore/syft/syft/pkg/cataloger/generic"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// unpinnedRequirements returns the requirements reported as unpinned in
+// the entries logged to hook
+func unpinnedRequirements(t *testing.T, hook *logtest.Hook) []string {
+	t.Helper()
+	ret := []string{}
+	for _, e := range hook.AllEntries() {
+		_, quoted, ok := strings.Cut(e.Message, "skipping unpinned requirement ")
+		if !ok || e.Level != logrus.WarnLevel {
+			continue
+		}
+		req, err := strconv.Unquote(quoted)
+		if err != nil {
+			t.Fatalf("unquoting %s: %v", quoted, err)
+		}
+		ret = append(ret, req)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// The fixtures describe a project that requires a (runtime), my-lib
+// (runtime, spelled differently in the lockfiles), o (optional extra) and d
+// (dev). In the lockfiles a depends on b@2 and on c through an extra, d
+// depends on b@1 (uv) or e (poetry).
+func TestParsePythonManifests(t *testing.T) {
+	direct := map[string]parsedPackage{
+		"a@1.0.0": {Direct: true, Scope: trusty.ScopeRuntime},
+		"o@1.0.0": {Direct: true, Scope: trusty.ScopeOptional},
+		"d@1.0.0": {Direct: true, Scope: trusty.ScopeDev},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		parser   generic.Parser
+		manifest string
+		packages map[string]parsedPackage
+		edges    []string
+		unpinned []string
+	}{
+		{
+			// a is also a dev dependency, the runtime scope wins
+			name: "uv", parser: parseUvLock, manifest: "uv.lock",
+			packages: withPythonPackages(direct, map[string]parsedPackage{
+				"My_Lib@1.0.0": {Direct: true, Scope: trusty.ScopeRuntime},
+				"b@1.0.0":      {Scope: trusty.ScopeRuntime},
+				"b@2.0.0":      {Scope: trusty.ScopeRuntime},
+				"c@1.0.0":      {Scope: trusty.ScopeRuntime},
+			}),
+			edges: []string{
+				"a@1.0.0 -> b@2.0.0 (runtime)",
+				"a@1.0.0 -> c@1.0.0 (optional)",
+				"d@1.0.0 -> b@1.0.0 (runtime)",
+			},
+			unpinned: []string{},
+		},
+		{
+			// Groups including other groups are skipped
+			name: "pep621", parser: parsePyproject, manifest: "pyproject.toml",
+			packages: withPythonPackages(direct, map[string]parsedPackage{
+				"my-lib@1.0.0": {Direct: true, Scope: trusty.ScopeRuntime},
+			}),
+			edges:    []string{},
+			unpinned: []string{"t~=1.0", "u>=1.0"},
+		},
+		{
+			// In poetry, a bare version is an exact pin
+			name: "poetry", parser: parsePyproject, manifest: "pyproject.toml",
+			packages: withPythonPackages(direct, map[string]parsedPackage{
+				"t@1.0.0": {Direct: true, Scope: trusty.ScopeDev},
+			}),
+			edges:    []string{},
+			unpinned: []string{"u ^2.0", "v map[git:https://github.com/example/v.git]"},
+		},
+		{
+			// The dependencies of projects with a lockfile are read
+			// from the lockfile
+			name: "poetry-locked", parser: parsePyproject, manifest: "pyproject.toml",
+			packages: map[string]parsedPackage{},
+			edges:    []string{},
+			unpinned: []string{},
+		},
+		{
+			// The direct dependencies are read from pyproject.toml, pinned
+			// or not. The others keep the scope of the groups that
+			// require them: e is only required by the dev group.
+			name: "poetry-locked", parser: parsePoetryLock, manifest: "poetry.lock",
+			packages: withPythonPackages(direct, map[string]parsedPackage{
+				"t@1.0.0": {Direct: true, Scope: trusty.ScopeDev},
+				"u@2.1.0": {Direct: true, Scope: trusty.ScopeRuntime},
+				"b@2.0.0": {Scope: trusty.ScopeRuntime},
+				"c@1.0.0": {Scope: trusty.ScopeOptional},
+				"e@1.0.0": {Scope: trusty.ScopeDev},
+			}),
+			edges: []string{
+				"a@1.0.0 -> b@2.0.0 (runtime)",
+				"a@1.0.0 -> c@1.0.0 (optional)",
+				"d@1.0.0 -> e@1.0.0 (runtime)",
+			},
+			unpinned: []string{},
+		},
+		{
+			// The develop packages are development dependencies unless
+			// they are also in the default section, the direct ones are
+			// read from the Pipfile. Packages without version are skipped.
+			name: "pipfile", parser: parsePipfileLock, manifest: "Pipfile.lock",
+			packages: map[string]parsedPackage{
+				"a@1.0.0":      {Direct: true, Scope: trusty.ScopeRuntime},
+				"my_lib@1.0.0": {Direct: true, Scope: trusty.ScopeRuntime},
+				"d@1.0.0":      {Direct: true, Scope: trusty.ScopeDev},
+				"b@2.0.0":      {Scope: trusty.ScopeRuntime},
+				"e@1.0.0":      {Scope: trusty.ScopeDev},
+			},
+			edges:    []string{},
+			unpinned: []string{},
+		},
+		{
+			// Options, other files and URLs are skipped
+			name: "requirements", parser: parseRequirements, manifest: "requirements.txt",
+			packages: map[string]parsedPackage{
+				"a@1.0.0":      {Scope: trusty.ScopeRuntime},
+				"my-lib@1.0.0": {Scope: trusty.ScopeRuntime},
+				"b@2.0.0":      {Scope: trusty.ScopeRuntime},
+			},
+			edges:    []string{},
+			unpinned: []string{"u>=1.0", "v", "w==1.*"},
+		},
+		{
+			name: "setup", parser: parseSetup, manifest: "setup.py",
+			packages: map[string]parsedPackage{
+				"a@1.0.0":      {Scope: trusty.ScopeRuntime},
+				"my-lib@1.0.0": {Scope: trusty.ScopeRuntime},
+			},
+			edges:    []string{},
+			unpinned: []string{},
+		},
+	} {
+		t.Run(tc.name+"/"+tc.manifest, func(t *testing.T) {
+			hook := logtest.NewGlobal()
+			defer hook.Reset()
+
+			pkgs, edges := parseFixture(t, tc.parser, "testdata/python/"+tc.name, tc.manifest)
+			packages := map[string]parsedPackage{}
+			for i := range pkgs {
+				p := &pkgs[i]
+				if want := "pkg:pypi/" + p.Name + "@" + p.Version; p.PURL != want {
+					t.Errorf("%s@%s: purl %q, want %q", p.Name, p.Version, p.PURL, want)
+				}
+				packages[p.Name+"@"+p.Version] = parsedMetadata(p)
+			}
+			if !reflect.DeepEqual(packages, tc.packages) {
+				t.Errorf("packages:\n got %v\nwant %v", packages, tc.packages)
+			}
+			if !reflect.DeepEqual(edges, tc.edges) {
+				t.Errorf("edges:\n got %q\nwant %q", edges, tc.edges)
+			}
+			if unpinned := unpinnedRequirements(t, hook); !reflect.DeepEqual(unpinned, tc.unpinned) {
+				t.Errorf("unpinned requirements:\n got %q\nwant %q", unpinned, tc.unpinned)
+			}
+		})
+	}
+}
+
+// withPythonPackages merges the package sets
+func withPythonPackages(sets ...map[string]parsedPackage) map[string]parsedPackage {
+	ret := map[string]parsedPackage{}
+	for _, s := range sets {
+		maps.Copy(ret, s)
+	}
+	return ret
+}
+
+func TestPoetryFlagScope(t *testing.T) {
+	for _, tc := range []struct {
+		category string
+		groups   []string
+		optional bool
+		scope    trusty.Scope
+	}{
+		{"main", nil, false, trusty.ScopeRuntime},
+		{"dev", nil, false, trusty.ScopeDev},
+		{"main", nil, true, trusty.ScopeOptional},
+		{"", []string{"main", "dev"}, false, trusty.ScopeRuntime},
+		{"", []string{"dev", "test"}, false, trusty.ScopeDev},
+		// lock-version 2.0 files record neither
+		{"", nil, false, trusty.ScopeRuntime},
+	} {
+		if scope := poetryFlagScope(tc.category, tc.groups, tc.optional); scope != tc.scope {
+			t.Errorf("%q %v optional=%v: expected %s, got %s", tc.category, tc.groups, tc.optional, tc.scope, scope)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+module example.com/app
+
+go 1.22
+
+require (
+	example.com/a v1.0.0
+	example.com/local v0.0.0
+	example.com/t v1.0.0
+)
+
+require (
+	example.com/b v1.1.0 // indirect
+	example.com/missing v1.0.0 // indirect
+	example.com/proxied v1.0.0 // indirect
+	example.com/tampered v1.0.0 // indirect
+)
+
+replace example.com/local => ./local
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"testing"
+
+	"example.com/a"
+	"example.com/t"
+)
+
+func TestApp(tt *testing.T) {
+	tt.Log(a.Name, t.Name)
+}
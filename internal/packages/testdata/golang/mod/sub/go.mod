@@ -0,0 +1,5 @@
+module example.com/app/sub
+
+go 1.22
+
+require example.com/t v1.0.0
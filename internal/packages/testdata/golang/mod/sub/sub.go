@@ -0,0 +1,5 @@
+package sub
+
+import "example.com/t"
+
+var Name = t.Name
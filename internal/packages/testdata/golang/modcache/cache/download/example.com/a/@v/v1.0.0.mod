@@ -0,0 +1,8 @@
+module example.com/a
+
+go 1.22
+
+require (
+	example.com/b v1.1.0
+	example.com/proxied v1.0.0
+)
@@ -0,0 +1,5 @@
+module example.com/t
+
+go 1.22
+
+require example.com/b v1.1.0
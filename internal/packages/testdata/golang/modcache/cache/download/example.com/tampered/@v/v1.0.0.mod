@@ -0,0 +1,5 @@
+module example.com/tampered
+
+go 1.22
+
+require example.com/b v1.1.0
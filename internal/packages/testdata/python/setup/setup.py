@@ -0,0 +1,11 @@
+from setuptools import setup
+
+setup(
+    name="app",
+    version="0.1.0",
+    install_requires=[
+        "a==1.0.0",
+        "u>=1.0",
+        "my-lib == 1.0.0; python_version >= '3.10'",
+    ],
+)
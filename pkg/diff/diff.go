@@ -0,0 +1,202 @@
+// Package diff compares the Trusty data of two sets of dependencies
+package diff
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+	"golang.org/x/mod/semver"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// ChangeType captures how a dependency changed between two sets
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Upgraded ChangeType = "upgraded"
+)
+
+// Change records a dependency that changed between the old and new set
+type Change struct {
+	Type       ChangeType           `json:"type"`
+	Key        string               `json:"key"`
+	Old        *trusty.PackageScore `json:"old,omitempty"`
+	New        *trusty.PackageScore `json:"new,omitempty"`
+	ScoreDelta float64              `json:"score_delta"`
+}
+
+// Introduced returns true if the change brings in a package that was not
+// risky or malicious before and is now.
+func (c *Change) Introduced() bool {
+	if c.New == nil {
+		return false
+	}
+	if c.New.Malicious && (c.Old == nil || !c.Old.Malicious) {
+		return true
+	}
+	if c.New.IsRisky() && (c.Old == nil || !c.Old.IsRisky()) {
+		return true
+	}
+	return false
+}
+
+// Report is the result of comparing two dependency sets
+type Report struct {
+	Added    []Change `json:"added"`
+	Removed  []Change `json:"removed"`
+	Upgraded []Change `json:"upgraded"`
+}
+
+// NewRisks returns the changes that introduce risky or malicious packages
+func (r *Report) NewRisks() []Change {
+	ret := []Change{}
+	for _, cs := range [][]Change{r.Added, r.Upgraded} {
+		for _, c := range cs {
+			if c.Introduced() {
+				ret = append(ret, c)
+			}
+		}
+	}
+	return ret
+}
+
+// Compare matches the packages in old and new by their purl (ignoring
+// the version) and returns a report of the dependencies that were added,
+// removed or upgraded.
+func Compare(oldSet, newSet []trusty.PackageScore) *Report {
+	oldIndex := indexScores(oldSet)
+	newIndex := indexScores(newSet)
+
+	report := &Report{
+		Added:    []Change{},
+		Removed:  []Change{},
+		Upgraded: []Change{},
+	}
+
+	keys := map[string]struct{}{}
+	for k := range oldIndex {
+		keys[k] = struct{}{}
+	}
+	for k := range newIndex {
+		keys[k] = struct{}{}
+	}
+
+	sortedKeys := []string{}
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, k := range sortedKeys {
+		olds, news := unmatchedVersions(oldIndex[k], newIndex[k])
+
+		// Pair the remaining versions as upgrades, anything left
+		// over was either added or removed.
+		for len(olds) > 0 && len(news) > 0 {
+			report.Upgraded = append(report.Upgraded, Change{
+				Type:       Upgraded,
+				Key:        k,
+				Old:        olds[0],
+				New:        news[0],
+				ScoreDelta: news[0].Score - olds[0].Score,
+			})
+			olds = olds[1:]
+			news = news[1:]
+		}
+
+		for _, o := range olds {
+			report.Removed = append(report.Removed, Change{
+				Type: Removed, Key: k, Old: o, ScoreDelta: -o.Score,
+			})
+		}
+
+		for _, n := range news {
+			report.Added = append(report.Added, Change{
+				Type: Added, Key: k, New: n, ScoreDelta: n.Score,
+			})
+		}
+	}
+
+	return report
+}
+
+// unmatchedVersions drops the versions found in both lists and returns
+// the rest sorted by version.
+func unmatchedVersions(olds, news []*trusty.PackageScore) (oldRet, newRet []*trusty.PackageScore) {
+	newVersions := map[string]struct{}{}
+	for _, n := range news {
+		newVersions[n.Version] = struct{}{}
+	}
+	oldVersions := map[string]struct{}{}
+	for _, o := range olds {
+		oldVersions[o.Version] = struct{}{}
+	}
+
+	oldRet = []*trusty.PackageScore{}
+	for _, o := range olds {
+		if _, ok := newVersions[o.Version]; !ok {
+			oldRet = append(oldRet, o)
+		}
+	}
+
+	newRet = []*trusty.PackageScore{}
+	for _, n := range news {
+		if _, ok := oldVersions[n.Version]; !ok {
+			newRet = append(newRet, n)
+		}
+	}
+
+	sort.SliceStable(oldRet, func(i, j int) bool { return compareVersions(oldRet[i].Version, oldRet[j].Version) < 0 })
+	sort.SliceStable(newRet, func(i, j int) bool { return compareVersions(newRet[i].Version, newRet[j].Version) < 0 })
+	return oldRet, newRet
+}
+
+// compareVersions orders two versions by semantic versioning when both
+// parse as semver (with or without the leading v) and as strings when
+// they don't or are semantically equal.
+func compareVersions(a, b string) int {
+	sa, sb := semverOf(a), semverOf(b)
+	if semver.IsValid(sa) && semver.IsValid(sb) {
+		if c := semver.Compare(sa, sb); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// semverOf returns a version with the v prefix expected by x/mod/semver
+func semverOf(v string) string {
+	if strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
+
+// indexScores indexes a list of scores by their versionless key
+func indexScores(scores []trusty.PackageScore) map[string][]*trusty.PackageScore {
+	index := map[string][]*trusty.PackageScore{}
+	for i := range scores {
+		k := packageKey(&scores[i])
+		index[k] = append(index[k], &scores[i])
+	}
+	return index
+}
+
+// packageKey returns the identifier used to match packages across sets. It
+// is the package's purl without version, qualifiers or subpath. If the
+// package has no purl, we fall back to ecosystem and name.
+func packageKey(s *trusty.PackageScore) string {
+	if p, ok := s.Identifiers["purl"]; ok {
+		purl, err := packageurl.FromString(p)
+		if err == nil {
+			return packageurl.NewPackageURL(
+				purl.Type, purl.Namespace, purl.Name, "", nil, "",
+			).ToString()
+		}
+	}
+	return strings.ToLower(s.Ecosystem) + "/" + s.Package
+}
@@ -0,0 +1,74 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+func scoresOf(versions ...string) []*trusty.PackageScore {
+	ret := []*trusty.PackageScore{}
+	for _, v := range versions {
+		ret = append(ret, &trusty.PackageScore{PackageInfo: trusty.PackageInfo{Package: "p", Version: v}})
+	}
+	return ret
+}
+
+func versionsOf(scores []*trusty.PackageScore) []string {
+	ret := []string{}
+	for _, s := range scores {
+		ret = append(ret, s.Version)
+	}
+	return ret
+}
+
+func TestUnmatchedVersions(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		olds     []string
+		news     []string
+		expected []string
+	}{
+		{"semver", []string{"1.10.0", "1.9.0", "2.0.0", "2.0.0-rc.1"}, nil, []string{"1.9.0", "1.10.0", "2.0.0-rc.1", "2.0.0"}},
+		{"go", []string{"v0.10.0", "v0.9.1"}, nil, []string{"v0.9.1", "v0.10.0"}},
+		{"matched", []string{"1.10.0", "1.2.0", "1.9.0"}, []string{"1.9.0"}, []string{"1.2.0", "1.10.0"}},
+		{"short", []string{"2023.10", "2023.9"}, nil, []string{"2023.9", "2023.10"}},
+		{"not-semver", []string{"2.0.post1", "1.0.0a1"}, nil, []string{"1.0.0a1", "2.0.post1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			olds, _ := unmatchedVersions(scoresOf(tc.olds...), scoresOf(tc.news...))
+			if got := versionsOf(olds); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("got %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCompareUpgrades(t *testing.T) {
+	// Versions are paired in semver order: 1.2.0 -> 1.2.1, 1.10.0 -> 1.11.0
+	purl := map[string]string{"purl": "pkg:npm/p"}
+	score := func(version string, s float64) trusty.PackageScore {
+		return trusty.PackageScore{PackageInfo: trusty.PackageInfo{Package: "p", Version: version, Identifiers: purl}, Score: s}
+	}
+	unscored := score("1.2.1", 0)
+	unscored.Unscored = true
+	report := Compare(
+		[]trusty.PackageScore{score("1.10.0", 6), score("1.2.0", 7)},
+		[]trusty.PackageScore{score("1.11.0", 8), unscored},
+	)
+
+	got := map[string]string{}
+	for _, c := range report.Upgraded {
+		got[c.Old.Version] = c.New.Version
+	}
+	want := map[string]string{"1.2.0": "1.2.1", "1.10.0": "1.11.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("upgrades: got %v, want %v", got, want)
+	}
+
+	// The unscored new version is not a new risk
+	if risks := report.NewRisks(); len(risks) != 0 {
+		t.Errorf("unexpected new risks: %+v", risks)
+	}
+}
@@ -1,11 +1,124 @@
 package display
 
 import (
+	"fmt"
 	"io"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/stacklok/trusty-attest/pkg/diff"
 	"github.com/stacklok/trusty-attest/pkg/trusty"
 )
 
 type Renderer interface {
 	DisplayResultSet(io.Writer, []trusty.PackageScore) error
 }
+
+// PredicateRenderer renders a predicate: a summary of its metadata
+// followed by the scored packages
+type PredicateRenderer interface {
+	DisplayPredicate(io.Writer, *trusty.Predicate) error
+}
+
+// DiffRenderer renders the comparison of two sets of dependencies
+type DiffRenderer interface {
+	DisplayDiff(io.Writer, *diff.Report) error
+}
+
+// diffHeaders are the columns of the tabular diff renderers
+var diffHeaders = []string{"PACKAGE", "OLD VERSION", "NEW VERSION", "OLD SCORE", "NEW SCORE", "DELTA", "MALICIOUS", "DEPRECATED"}
+
+// diffRow returns the columns of a change in tabular renderers
+func diffRow(c *diff.Change, flags map[bool]string) []string {
+	row := []string{c.Key, "", "", "", "", fmt.Sprintf("%+.2f", c.ScoreDelta), "", ""}
+	if c.Old != nil {
+		row[1] = c.Old.Version
+		row[3] = fmt.Sprintf("%.2f", c.Old.Score)
+	}
+	if c.New != nil {
+		row[2] = c.New.Version
+		row[4] = fmt.Sprintf("%.2f", c.New.Score)
+		row[6] = flags[c.New.Malicious]
+		row[7] = flags[c.New.Deprecated]
+	}
+	return row
+}
+
+// metadataSummary returns the labels and values of the predicate metadata
+// shown in the summaries. Unset values are skipped.
+func metadataSummary(p *trusty.Predicate) [][2]string {
+	md := &p.Metadata
+	rows := [][2]string{}
+	add := func(label, value string) {
+		if value != "" {
+			rows = append(rows, [2]string{label, value})
+		}
+	}
+
+	pkg := md.Package
+	if md.Version != "" {
+		pkg += "@" + md.Version
+	}
+	add("Package", pkg)
+	add("Purl", md.Identifiers["purl"])
+	if s := md.Source; s != nil {
+		src := s.Repository
+		if s.Commit != "" {
+			src = strings.TrimSpace(src + " " + s.Commit)
+		}
+		if s.Tag != "" {
+			src += " (" + s.Tag + ")"
+		}
+		add("Source", src)
+	}
+	if in := md.Input; in != nil {
+		add("Input", strings.TrimSpace(in.Type+" "+in.Path))
+	}
+	add("Ecosystems", strings.Join(md.Ecosystems, ", "))
+	if md.Date != nil {
+		add("Date", md.Date.Format(time.RFC3339))
+	}
+	if t := md.Tool; t != nil {
+		add("Tool", strings.TrimSpace(t.Name+" "+t.Version))
+	}
+	add("Endpoint", md.Endpoint)
+	if o := md.Options; o != nil {
+		add("Scope", o.Scope)
+	}
+	return rows
+}
+
+// statsSummary returns the labels and values of the predicate summary
+// statistics. Predicates written without a summary get it computed from
+// their packages.
+func statsSummary(p *trusty.Predicate) [][2]string {
+	s := p.Summary
+	if s == nil {
+		s = trusty.Summarize(p.Packages)
+	}
+
+	total := fmt.Sprintf("%d", s.Total)
+	if len(s.Ecosystems) > 0 {
+		ecos := []string{}
+		for e, n := range s.Ecosystems {
+			ecos = append(ecos, fmt.Sprintf("%s %d", e, n))
+		}
+		slices.Sort(ecos)
+		total += " (" + strings.Join(ecos, ", ") + ")"
+	}
+	rows := [][2]string{
+		{"Packages", total},
+		{"Scores", fmt.Sprintf(
+			"%d low, %d medium, %d high, %d unscored",
+			s.ScoreBands.Low, s.ScoreBands.Medium, s.ScoreBands.High, s.Unscored,
+		)},
+	}
+	if s.MinScore != nil && s.MedianScore != nil {
+		rows = append(rows, [2]string{"Min/median", fmt.Sprintf("%.2f / %.2f", *s.MinScore, *s.MedianScore)})
+	}
+	return append(rows,
+		[2]string{"Malicious", fmt.Sprintf("%d", s.Malicious)},
+		[2]string{"Deprecated", fmt.Sprintf("%d", s.Deprecated)},
+	)
+}
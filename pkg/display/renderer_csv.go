@@ -18,13 +18,11 @@ var intLabels = map[bool]string{
 
 func (cr *CsvRenderer) DisplayResultSet(w io.Writer, res []trusty.PackageScore) error {
 	records := [][]string{
-		{"ecosystem", "name", "version", "purl", "score", "activity", "provenance", "deprecated", "malicious"},
+		{"ecosystem", "name", "version", "purl", "score", "provenance", "deprecated", "malicious"},
 	}
 	for _, r := range res {
 		records = append(records, []string{
 			strings.ToLower(r.Ecosystem), r.Package, r.Version, r.Identifiers["purl"],
-			// TODO(puerco): Add activity
-			"0",
 			fmt.Sprintf("%f", r.Score), fmt.Sprintf("%f", r.ProvenanceScore),
 			intLabels[r.Deprecated], intLabels[r.Malicious],
 		})
@@ -35,3 +33,9 @@ func (cr *CsvRenderer) DisplayResultSet(w io.Writer, res []trusty.PackageScore)
 	}
 	return nil
 }
+
+// DisplayPredicate writes the packages of the predicate, the metadata does
+// not fit the CSV records and is left out
+func (cr *CsvRenderer) DisplayPredicate(w io.Writer, p *trusty.Predicate) error {
+	return cr.DisplayResultSet(w, p.Packages)
+}
@@ -0,0 +1,45 @@
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/stacklok/trusty-attest/pkg/diff"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+type JSONRenderer struct{}
+
+func (jr *JSONRenderer) DisplayDiff(w io.Writer, report *diff.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(struct {
+		*diff.Report
+		NewRisks []diff.Change `json:"new_risks"`
+	}{report, report.NewRisks()}); err != nil {
+		return fmt.Errorf("encoding diff: %w", err)
+	}
+	return nil
+}
+
+func (jr *JSONRenderer) DisplayResultSet(w io.Writer, res []trusty.PackageScore) error {
+	return jr.encode(w, res)
+}
+
+// DisplayPredicate writes the whole predicate as JSON
+func (jr *JSONRenderer) DisplayPredicate(w io.Writer, p *trusty.Predicate) error {
+	return jr.encode(w, p)
+}
+
+func (jr *JSONRenderer) encode(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+	return nil
+}
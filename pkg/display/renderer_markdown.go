@@ -0,0 +1,101 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stacklok/trusty-attest/pkg/diff"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+type MarkdownRenderer struct{}
+
+var markdownBool = map[bool]string{
+	true: "yes", false: "",
+}
+
+func (mr *MarkdownRenderer) DisplayDiff(w io.Writer, report *diff.Report) error {
+	sections := []struct {
+		title   string
+		changes []diff.Change
+	}{
+		{"⚠️ Newly introduced risky or malicious dependencies", report.NewRisks()},
+		{"Added dependencies", report.Added},
+		{"Removed dependencies", report.Removed},
+		{"Upgraded dependencies", report.Upgraded},
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Dependency trust diff\n")
+	for _, s := range sections {
+		sb.WriteString(fmt.Sprintf("\n## %s (%d)\n\n", s.title, len(s.changes)))
+		if len(s.changes) == 0 {
+			sb.WriteString("_None_\n")
+			continue
+		}
+		sb.WriteString(markdownRow(diffHeaders))
+		sb.WriteString(strings.Repeat("| --- ", len(diffHeaders)) + "|\n")
+		for i := range s.changes {
+			sb.WriteString(markdownRow(diffRow(&s.changes[i], markdownBool)))
+		}
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("writing markdown diff: %w", err)
+	}
+	return nil
+}
+
+// resultHeaders are the columns of the markdown packages table
+var resultHeaders = []string{"PACKAGE", "VERSION", "SCOPE", "SCORE", "PROVENANCE", "MALICIOUS", "DEPRECATED"}
+
+func (mr *MarkdownRenderer) DisplayResultSet(w io.Writer, res []trusty.PackageScore) error {
+	var sb strings.Builder
+	sb.WriteString(markdownRow(resultHeaders))
+	sb.WriteString(strings.Repeat("| --- ", len(resultHeaders)) + "|\n")
+	for _, r := range res {
+		name := r.Identifiers["purl"]
+		if name == "" {
+			name = r.Package
+		}
+		sb.WriteString(markdownRow([]string{
+			name, r.Version, string(r.Scope),
+			fmt.Sprintf("%.2f", r.Score), fmt.Sprintf("%.2f", r.ProvenanceScore),
+			markdownBool[r.Malicious], markdownBool[r.Deprecated],
+		}))
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("writing markdown results: %w", err)
+	}
+	return nil
+}
+
+// DisplayPredicate writes the predicate metadata as a list followed by the
+// table of packages
+func (mr *MarkdownRenderer) DisplayPredicate(w io.Writer, p *trusty.Predicate) error {
+	var sb strings.Builder
+	sb.WriteString("# Trusty attestation\n\n")
+	for _, row := range metadataSummary(p) {
+		sb.WriteString(fmt.Sprintf("- **%s:** %s\n", row[0], row[1]))
+	}
+	sb.WriteString("\n## Summary\n\n")
+	for _, row := range statsSummary(p) {
+		sb.WriteString(fmt.Sprintf("- **%s:** %s\n", row[0], row[1]))
+	}
+	sb.WriteString("\n## Packages\n\n")
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("writing markdown metadata: %w", err)
+	}
+	return mr.DisplayResultSet(w, p.Packages)
+}
+
+// markdownRow formats a row of a markdown table
+func markdownRow(cols []string) string {
+	escaped := make([]string, len(cols))
+	for i, c := range cols {
+		escaped[i] = strings.ReplaceAll(c, "|", `\|`)
+	}
+	return "| " + strings.Join(escaped, " | ") + " |\n"
+}
@@ -3,10 +3,10 @@ package display
 import (
 	"fmt"
 	"io"
-	"strconv"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/stacklok/trusty-attest/pkg/diff"
 	"github.com/stacklok/trusty-attest/pkg/trusty"
 )
 
@@ -49,8 +49,7 @@ func (tr *TermRenderer) DisplayResultSet(w io.Writer, res []trusty.PackageScore)
 			if row > 0 {
 				// row -1 because the row number is off by 1 from the data
 				// because of the inserted header
-				f, err := strconv.ParseFloat(rows[row-1][4], 64)
-				if err == nil && f <= 5 {
+				if res[row-1].IsRisky() {
 					return riskyStyle
 				}
 			}
@@ -73,3 +72,94 @@ func (tr *TermRenderer) DisplayResultSet(w io.Writer, res []trusty.PackageScore)
 	fmt.Fprintln(w, "")
 	return nil
 }
+
+// DisplayPredicate prints a header with the predicate metadata and summary
+// statistics followed by the table of packages
+func (tr *TermRenderer) DisplayPredicate(w io.Writer, p *trusty.Predicate) error {
+	labelStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4")).Width(12)
+	alertStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF0000"))
+	metadata := metadataSummary(p)
+	for _, row := range metadata {
+		if _, err := fmt.Fprintln(w, labelStyle.Render(row[0])+row[1]); err != nil {
+			return fmt.Errorf("rendering metadata: %w", err)
+		}
+	}
+	if len(metadata) > 0 {
+		fmt.Fprintln(w, "")
+	}
+	for _, row := range statsSummary(p) {
+		value := row[1]
+		if (row[0] == "Malicious" || row[0] == "Deprecated") && value != "0" {
+			value = alertStyle.Render(value)
+		}
+		if _, err := fmt.Fprintln(w, labelStyle.Render(row[0])+value); err != nil {
+			return fmt.Errorf("rendering summary: %w", err)
+		}
+	}
+	return tr.DisplayResultSet(w, p.Packages)
+}
+
+func (tr *TermRenderer) DisplayDiff(w io.Writer, report *diff.Report) error {
+	titleStyle := lipgloss.NewStyle().Bold(true).MarginTop(1)
+	alertStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Bold(true).MarginTop(1)
+
+	sections := []struct {
+		title   string
+		style   lipgloss.Style
+		changes []diff.Change
+	}{
+		{"Newly introduced risky or malicious dependencies", alertStyle, report.NewRisks()},
+		{"Added dependencies", titleStyle, report.Added},
+		{"Removed dependencies", titleStyle, report.Removed},
+		{"Upgraded dependencies", titleStyle, report.Upgraded},
+	}
+
+	for _, s := range sections {
+		if len(s.changes) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, s.style.Render(fmt.Sprintf("%s (%d)", s.title, len(s.changes)))); err != nil {
+			return fmt.Errorf("rendering diff: %w", err)
+		}
+		if err := tr.renderChanges(w, s.changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderChanges prints a table with a list of changes
+func (tr *TermRenderer) renderChanges(w io.Writer, changes []diff.Change) error {
+	var rows = [][]string{}
+	for i := range changes {
+		rows = append(rows, diffRow(&changes[i], emojiBool))
+	}
+
+	riskyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).
+		Bold(true).PaddingLeft(1).PaddingRight(1)
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FAFAFA")).
+		Bold(true).Background(lipgloss.Color("#7D56F4")).PaddingLeft(1).PaddingRight(1)
+
+	cellStyle := lipgloss.NewStyle().PaddingLeft(1).PaddingRight(1)
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99"))).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return headerStyle
+			}
+			if changes[row-1].Introduced() {
+				return riskyStyle
+			}
+			return cellStyle
+		}).
+		Headers(diffHeaders...).
+		Rows(rows...)
+
+	if _, err := fmt.Fprintln(w, t); err != nil {
+		return fmt.Errorf("rendering diff: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package policy evaluates the dependencies recorded in a Trusty predicate
+// against a set of rules
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/trusty-attest/pkg/sbom"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// Rule names reported in violations
+const (
+	RuleMinScore   = "min-score"
+	RuleMalicious  = "malicious"
+	RuleDeprecated = "deprecated"
+	RuleUnscored   = "unscored"
+)
+
+// Policy captures the rules the packages of a predicate must follow
+type Policy struct {
+	// MinScore is the lowest Trusty score accepted, unset disables the check.
+	// Packages Trusty has no score for are left to DenyUnscored.
+	MinScore *float64 `json:"minScore,omitempty" yaml:"minScore,omitempty"`
+	// DenyMalicious rejects packages flagged as malicious
+	DenyMalicious bool `json:"denyMalicious,omitempty" yaml:"denyMalicious,omitempty"`
+	// DenyDeprecated rejects deprecated packages
+	DenyDeprecated bool `json:"denyDeprecated,omitempty" yaml:"denyDeprecated,omitempty"`
+	// DenyUnscored rejects packages Trusty has no score for
+	DenyUnscored bool `json:"denyUnscored,omitempty" yaml:"denyUnscored,omitempty"`
+	// Scope limits the rules to runtime or dev dependencies (default all)
+	Scope string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	// Ignore lists package names exempted from the rules
+	Ignore []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+}
+
+// Violation is a package that breaks a rule of the policy
+type Violation struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Result is the outcome of evaluating a policy
+type Result struct {
+	Passed     bool        `json:"passed"`
+	Violations []Violation `json:"violations"`
+}
+
+// Load reads a policy from a YAML (or JSON) file
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy: %w", err)
+	}
+	p := &Policy{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("decoding policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	return p, nil
+}
+
+// Validate checks the policy settings
+func (p *Policy) Validate() error {
+	errs := []error{}
+	if p.MinScore != nil && (*p.MinScore < 0 || *p.MinScore > 10) {
+		errs = append(errs, fmt.Errorf("minScore must be between 0 and 10"))
+	}
+	if p.Scope != "" {
+		if err := sbom.ValidateScopeFilter(p.Scope); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Evaluate checks the packages in the predicate against the policy
+func (p *Policy) Evaluate(pred *trusty.Predicate) *Result {
+	res := &Result{Violations: []Violation{}}
+	for i := range pred.Packages {
+		ps := &pred.Packages[i]
+		if slices.Contains(p.Ignore, ps.Package) {
+			continue
+		}
+		if !sbom.ScopeIncluded(p.Scope, ps.Scope) {
+			continue
+		}
+		if p.DenyMalicious && ps.Malicious {
+			res.add(ps, RuleMalicious, "package is flagged as malicious")
+		}
+		if p.DenyDeprecated && ps.Deprecated {
+			res.add(ps, RuleDeprecated, "package is deprecated")
+		}
+		if p.DenyUnscored && ps.Unscored {
+			res.add(ps, RuleUnscored, "package has no Trusty score")
+		}
+		if p.MinScore != nil && !ps.Unscored && ps.Score < *p.MinScore {
+			res.add(ps, RuleMinScore, fmt.Sprintf("score %.1f is below %.1f", ps.Score, *p.MinScore))
+		}
+	}
+	res.Passed = len(res.Violations) == 0
+	return res
+}
+
+func (r *Result) add(ps *trusty.PackageScore, rule, msg string) {
+	r.Violations = append(r.Violations, Violation{
+		Package: ps.Package,
+		Version: ps.Version,
+		Rule:    rule,
+		Message: msg,
+	})
+}
@@ -0,0 +1,122 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+func TestLoad(t *testing.T) {
+	p, err := Load("testdata/policy.yaml")
+	if err != nil {
+		t.Fatalf("loading policy: %v", err)
+	}
+	if p.MinScore == nil || *p.MinScore != 6 {
+		t.Errorf("minScore = %v, want 6", p.MinScore)
+	}
+	if !p.DenyMalicious || p.DenyDeprecated {
+		t.Errorf("denyMalicious = %v, denyDeprecated = %v", p.DenyMalicious, p.DenyDeprecated)
+	}
+	if p.Scope != "runtime" || !slices.Equal(p.Ignore, []string{"ok"}) {
+		t.Errorf("scope = %q, ignore = %v", p.Scope, p.Ignore)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"score out of range": "minScore: 11\n",
+		"negative score":     "minScore: -1\n",
+		"unknown scope":      "scope: optional\n",
+		"not yaml":           "minScore: [\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Error("expected an error loading the policy")
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	minScore := 6.0
+	for _, tc := range []struct {
+		name       string
+		policy     Policy
+		packages   []trusty.PackageScore
+		violations []Violation
+	}{
+		{
+			name:     "empty policy passes",
+			policy:   Policy{},
+			packages: []trusty.PackageScore{{PackageInfo: trusty.PackageInfo{Package: "a"}, Score: 1, Malicious: true}},
+		},
+		{
+			name:   "every rule",
+			policy: Policy{MinScore: &minScore, DenyMalicious: true, DenyDeprecated: true},
+			packages: []trusty.PackageScore{
+				{PackageInfo: trusty.PackageInfo{Package: "a", Version: "1"}, Score: 3, Malicious: true},
+				{PackageInfo: trusty.PackageInfo{Package: "b", Version: "2"}, Score: 7, Deprecated: true},
+				{PackageInfo: trusty.PackageInfo{Package: "c", Version: "3"}, Score: 9},
+			},
+			violations: []Violation{
+				{Package: "a", Version: "1", Rule: RuleMalicious, Message: "package is flagged as malicious"},
+				{Package: "a", Version: "1", Rule: RuleMinScore, Message: "score 3.0 is below 6.0"},
+				{Package: "b", Version: "2", Rule: RuleDeprecated, Message: "package is deprecated"},
+			},
+		},
+		{
+			name:   "scope and ignore",
+			policy: Policy{MinScore: &minScore, DenyMalicious: true, Scope: "runtime", Ignore: []string{"ok"}},
+			packages: []trusty.PackageScore{
+				{PackageInfo: trusty.PackageInfo{Package: "a"}, Score: 3, Malicious: true, Scope: trusty.ScopeRuntime},
+				{PackageInfo: trusty.PackageInfo{Package: "b"}, Score: 3, Scope: trusty.ScopeDev},
+				{PackageInfo: trusty.PackageInfo{Package: "ok"}, Score: 1},
+			},
+			violations: []Violation{
+				{Package: "a", Rule: RuleMalicious, Message: "package is flagged as malicious"},
+				{Package: "a", Rule: RuleMinScore, Message: "score 3.0 is below 6.0"},
+			},
+		},
+		{
+			// The zero score of unscored packages is not compared to the
+			// minimum, a zero score is
+			name:   "unscored",
+			policy: Policy{MinScore: &minScore},
+			packages: []trusty.PackageScore{
+				{PackageInfo: trusty.PackageInfo{Package: "a"}, Unscored: true},
+				{PackageInfo: trusty.PackageInfo{Package: "b"}},
+			},
+			violations: []Violation{
+				{Package: "b", Rule: RuleMinScore, Message: "score 0.0 is below 6.0"},
+			},
+		},
+		{
+			name:   "deny unscored",
+			policy: Policy{MinScore: &minScore, DenyUnscored: true},
+			packages: []trusty.PackageScore{
+				{PackageInfo: trusty.PackageInfo{Package: "a"}, Unscored: true},
+				{PackageInfo: trusty.PackageInfo{Package: "b"}, Score: 7},
+			},
+			violations: []Violation{
+				{Package: "a", Rule: RuleUnscored, Message: "package has no Trusty score"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res := tc.policy.Evaluate(&trusty.Predicate{Packages: tc.packages})
+			if res.Passed != (len(tc.violations) == 0) {
+				t.Errorf("passed = %v with %d violations", res.Passed, len(res.Violations))
+			}
+			if !slices.Equal(res.Violations, tc.violations) {
+				t.Errorf("violations = %+v, want %+v", res.Violations, tc.violations)
+			}
+		})
+	}
+}
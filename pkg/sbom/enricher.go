@@ -0,0 +1,181 @@
+package sbom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/protobom/protobom/pkg/formats"
+	"github.com/protobom/protobom/pkg/reader"
+	"github.com/protobom/protobom/pkg/sbom"
+	"github.com/sirupsen/logrus"
+	"github.com/spdx/tools-golang/spdx"
+	"github.com/spdx/tools-golang/spdx/v2/common"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// propertyPrefix is the namespace of the properties and annotations
+// added to enriched SBOMs
+const propertyPrefix = "trusty:"
+
+// Enricher scores the nodes of an SBOM and writes them back embedding
+// the Trusty data in the native document.
+type Enricher struct {
+	scorer *Scorer
+}
+
+func NewEnricher() *Enricher {
+	return &Enricher{
+		scorer: NewScorer(),
+	}
+}
+
+// EnrichSBOM parses an SBOM from f, scores it and writes it to w in the
+// specified format. If format is blank, the input format is preserved.
+func (e *Enricher) EnrichSBOM(ctx context.Context, f io.ReadSeeker, w io.WriteCloser, format formats.Format) error {
+	if format == "" {
+		sniffer := formats.Sniffer{}
+		sniffed, err := sniffer.SniffReader(f)
+		if err != nil {
+			return fmt.Errorf("detecting SBOM format: %w", err)
+		}
+		format = sniffed
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewinding SBOM: %w", err)
+		}
+	}
+
+	r := reader.New()
+	doc, err := r.ParseStream(f)
+	if err != nil {
+		return fmt.Errorf("unable to parse file: %w", err)
+	}
+
+	scores, err := e.ScoreDocument(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	return e.WriteDocument(doc, scores, w, format)
+}
+
+// ScoreDocument scores all the nodes in a document and returns the
+// results indexed by node ID.
+func (e *Enricher) ScoreDocument(ctx context.Context, doc *sbom.Document) (map[string]*trusty.PackageScore, error) {
+	scores := map[string]*trusty.PackageScore{}
+	for _, n := range doc.NodeList.Nodes {
+		score, err := e.scorer.ScoreNode(ctx, n)
+		if score == nil {
+			continue
+		}
+		if err != nil {
+			if _, ok := err.(TrustyAPIError); ok {
+				logrus.Errorf("error fetching score for %q", n.Purl())
+				continue
+			}
+			return nil, fmt.Errorf("fetching data from trusty: %w", err)
+		}
+		scores[n.Id] = score
+	}
+	return scores, nil
+}
+
+// WriteDocument serializes doc to w embedding the scores in the
+// native document.
+func (e *Enricher) WriteDocument(doc *sbom.Document, scores map[string]*trusty.PackageScore, w io.WriteCloser, format formats.Format) error {
+	es := &enricher{scores: scores}
+	if err := writeNative(doc, format, w, es.enrich); err != nil {
+		return fmt.Errorf("writing enriched SBOM: %w", err)
+	}
+	return nil
+}
+
+// enricher adds the scores to the native documents serialized by protobom
+type enricher struct {
+	scores map[string]*trusty.PackageScore
+}
+
+func (es *enricher) enrich(nativeDoc interface{}) error {
+	switch d := nativeDoc.(type) {
+	case *cdx.BOM:
+		if d.Metadata != nil && d.Metadata.Component != nil {
+			es.enrichComponent(d.Metadata.Component)
+		}
+		if d.Components != nil {
+			es.enrichComponents(d.Components)
+		}
+	case *spdx.Document:
+		es.enrichPackages(d)
+	default:
+		return fmt.Errorf("unable to enrich documents of type %T", nativeDoc)
+	}
+	return nil
+}
+
+func (es *enricher) enrichComponents(comps *[]cdx.Component) {
+	for i := range *comps {
+		es.enrichComponent(&(*comps)[i])
+		if (*comps)[i].Components != nil {
+			es.enrichComponents((*comps)[i].Components)
+		}
+	}
+}
+
+func (es *enricher) enrichComponent(c *cdx.Component) {
+	score, ok := es.scores[c.BOMRef]
+	if !ok {
+		return
+	}
+	props := []cdx.Property{}
+	if c.Properties != nil {
+		props = *c.Properties
+	}
+	for _, kv := range scoreValues(score) {
+		props = append(props, cdx.Property{Name: propertyPrefix + kv[0], Value: kv[1]})
+	}
+	c.Properties = &props
+}
+
+func (es *enricher) enrichPackages(doc *spdx.Document) {
+	date := time.Now().UTC().Format(time.RFC3339)
+	for _, p := range doc.Packages {
+		score, ok := es.scores[string(p.PackageSPDXIdentifier)]
+		if !ok {
+			continue
+		}
+		for _, kv := range scoreValues(score) {
+			p.Annotations = append(p.Annotations, spdx.Annotation{
+				Annotator: common.Annotator{
+					Annotator:     "trusty",
+					AnnotatorType: "Tool",
+				},
+				AnnotationDate: date,
+				AnnotationType: "OTHER",
+				AnnotationSPDXIdentifier: common.DocElementID{
+					ElementRefID: p.PackageSPDXIdentifier,
+				},
+				AnnotationComment: propertyPrefix + kv[0] + "=" + kv[1],
+			})
+		}
+	}
+}
+
+// scoreValues returns the values of a score to embed in the SBOM. The
+// activity score is left out while the scorer does not read it.
+func scoreValues(s *trusty.PackageScore) [][2]string {
+	ret := [][2]string{
+		{"score", strconv.FormatFloat(s.Score, 'f', -1, 64)},
+		{"provenance", strconv.FormatFloat(s.ProvenanceScore, 'f', -1, 64)},
+	}
+	if s.ActivityScore != 0 {
+		ret = append(ret, [2]string{"activity", strconv.FormatFloat(s.ActivityScore, 'f', -1, 64)})
+	}
+	return append(ret,
+		[2]string{"malicious", strconv.FormatBool(s.Malicious)},
+		[2]string{"deprecated", strconv.FormatBool(s.Deprecated)},
+	)
+}
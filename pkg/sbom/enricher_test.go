@@ -0,0 +1,78 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/protobom/protobom/pkg/formats"
+	"github.com/protobom/protobom/pkg/sbom"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+func TestWriteDocument(t *testing.T) {
+	doc := sbom.NewDocument()
+	doc.Metadata.Id = "urn:uuid:00000000-0000-0000-0000-000000000000"
+	doc.NodeList.AddRootNode(&sbom.Node{Id: "root", Name: "app"})
+	for _, name := range []string{"a", "b"} {
+		doc.NodeList.RelateNodeAtID(&sbom.Node{
+			Id:          name,
+			Name:        name,
+			Identifiers: map[int32]string{int32(sbom.SoftwareIdentifierType_PURL): "pkg:npm/" + name + "@1.0.0"},
+		}, "root", sbom.Edge_dependsOn)
+	}
+	scores := map[string]*trusty.PackageScore{
+		"a": {Score: 7.5, ProvenanceScore: 3, Malicious: true},
+	}
+
+	b := bytes.Buffer{}
+	if err := NewEnricher().WriteDocument(doc, scores, nopCloser{&b}, formats.CDX15JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	bom := cdx.BOM{}
+	if err := json.Unmarshal(b.Bytes(), &bom); err != nil {
+		t.Fatal(err)
+	}
+
+	// The activity score is not set, it must not be written
+	want := map[string]string{
+		"trusty:score":      "7.5",
+		"trusty:provenance": "3",
+		"trusty:malicious":  "true",
+		"trusty:deprecated": "false",
+	}
+	found := false
+	var walk func(comps *[]cdx.Component)
+	walk = func(comps *[]cdx.Component) {
+		if comps == nil {
+			return
+		}
+		for _, c := range *comps {
+			walk(c.Components)
+			props := map[string]string{}
+			if c.Properties != nil {
+				for _, p := range *c.Properties {
+					props[p.Name] = p.Value
+				}
+			}
+			if c.BOMRef != "a" {
+				if len(props) != 0 {
+					t.Errorf("unscored component %s has properties %v", c.BOMRef, props)
+				}
+				continue
+			}
+			found = true
+			if !reflect.DeepEqual(props, want) {
+				t.Errorf("properties: got %v, want %v", props, want)
+			}
+		}
+	}
+	walk(bom.Components)
+	if !found {
+		t.Error("scored component not written")
+	}
+}
@@ -0,0 +1,185 @@
+package sbom
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+	"github.com/protobom/protobom/pkg/formats"
+	"github.com/protobom/protobom/pkg/sbom"
+	"github.com/spdx/tools-golang/spdx"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sigs.k8s.io/release-utils/version"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// WriteNodeList wraps a node list in an SBOM document named name and writes
+// it to w in the specified format. The document is dated at date or, if nil,
+// at the current time. Its ID is derived from the document contents so that
+// the same node list written at the same date gives the same document.
+func WriteNodeList(nl *sbom.NodeList, name string, date *time.Time, w io.WriteCloser, format formats.Format) error {
+	doc := sbom.NewDocument()
+	doc.Metadata.Version = "1"
+	doc.Metadata.Name = name
+	doc.Metadata.Date = timestamppb.Now()
+	if date != nil {
+		doc.Metadata.Date = timestamppb.New(*date)
+	}
+	doc.Metadata.Tools = append(doc.Metadata.Tools, &sbom.Tool{
+		Name:    trusty.ToolName,
+		Version: version.GetVersionInfo().GitVersion,
+		Vendor:  "Stacklok",
+	})
+	nl = canonicalNodeList(nl)
+	doc.NodeList = nl
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("hashing SBOM: %w", err)
+	}
+	doc.Metadata.Id = "urn:uuid:" + uuid.NewSHA1(uuid.NameSpaceURL, data).String()
+	created := doc.Metadata.Date.AsTime().UTC().Format(time.RFC3339)
+
+	// The protobom CycloneDX serializer nests the components reached by an
+	// edge and drops the edges it cannot map. We serialize the nodes without
+	// edges to get a flat component list and write the dependency graph
+	// ourselves.
+	if format.Type() == formats.CDXFORMAT {
+		doc.NodeList = nl.Copy()
+		doc.NodeList.Edges = []*sbom.Edge{}
+	}
+
+	// The serializers don't record the document date and SPDX documents get
+	// a fixed namespace, both are set in the native document.
+	err = writeNative(doc, format, w, func(nativeDoc interface{}) error {
+		switch d := nativeDoc.(type) {
+		case *cdx.BOM:
+			deps := cdxDependencies(nl)
+			d.Dependencies = &deps
+			if d.Metadata != nil {
+				d.Metadata.Timestamp = created
+			}
+			sortComponents(d.Components)
+		case *spdx.Document:
+			d.DocumentNamespace = spdxNamespace + strings.TrimPrefix(doc.Metadata.Id, "urn:uuid:")
+			if d.CreationInfo != nil {
+				d.CreationInfo.Created = created
+			}
+		default:
+			return fmt.Errorf("unable to write documents of type %T", nativeDoc)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("writing SBOM: %w", err)
+	}
+	return nil
+}
+
+// spdxNamespace prefixes the namespace of the SPDX documents written, the
+// document ID is appended to it
+const spdxNamespace = "https://spdx.org/spdxdocs/trusty-"
+
+// canonicalNodeList returns a copy of the node list with its nodes sorted by
+// name, version and ID and its edges by source, type and targets. The
+// parsers build the package lists iterating over maps, sorting keeps the
+// order of the documents stable.
+func canonicalNodeList(nl *sbom.NodeList) *sbom.NodeList {
+	ret := nl.Copy()
+	sort.SliceStable(ret.Nodes, func(i, j int) bool {
+		a, b := ret.Nodes[i], ret.Nodes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Id < b.Id
+	})
+	for _, e := range ret.Edges {
+		sort.Strings(e.To)
+	}
+	sort.SliceStable(ret.Edges, func(i, j int) bool {
+		a, b := ret.Edges[i], ret.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return strings.Join(a.To, ",") < strings.Join(b.To, ",")
+	})
+	sort.Strings(ret.RootElements)
+	return ret
+}
+
+// sortComponents sorts CycloneDX components by name, version and reference.
+// protobom collects them in a map, which loses the node order.
+func sortComponents(comps *[]cdx.Component) {
+	if comps == nil {
+		return
+	}
+	sort.SliceStable(*comps, func(i, j int) bool {
+		a, b := (*comps)[i], (*comps)[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.BOMRef < b.BOMRef
+	})
+}
+
+// cdxDependencies returns the CycloneDX dependency graph of a node list.
+// CycloneDX dependencies have no scope, all dependency edges are
+// recorded as plain dependencies.
+func cdxDependencies(nl *sbom.NodeList) []cdx.Dependency {
+	order := []string{}
+	dependsOn := map[string][]string{}
+	add := func(from, to string) {
+		if _, ok := dependsOn[from]; !ok {
+			order = append(order, from)
+		}
+		dependsOn[from] = append(dependsOn[from], to)
+	}
+	for _, e := range nl.Edges {
+		if _, ok := trusty.EdgeScope(e.Type); !ok {
+			continue
+		}
+		for _, to := range e.To {
+			if trusty.DependencyOfEdge(e.Type) {
+				add(to, e.From)
+			} else {
+				add(e.From, to)
+			}
+		}
+	}
+
+	ret := []cdx.Dependency{}
+	for _, ref := range order {
+		deps := dedupe(dependsOn[ref])
+		ret = append(ret, cdx.Dependency{Ref: ref, Dependencies: &deps})
+	}
+	return ret
+}
+
+// dedupe returns the strings in s without duplicates, keeping the order
+func dedupe(s []string) []string {
+	seen := map[string]struct{}{}
+	ret := []string{}
+	for _, v := range s {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		ret = append(ret, v)
+	}
+	return ret
+}
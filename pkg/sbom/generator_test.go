@@ -0,0 +1,63 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/protobom/protobom/pkg/formats"
+)
+
+// nopCloser adapts a buffer to the writer interface of WriteNodeList
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestWriteNodeListReproducible(t *testing.T) {
+	date := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		format formats.Format
+		id     func(map[string]any) any
+		date   func(map[string]any) any
+	}{
+		{
+			format: formats.SPDX23JSON,
+			id:     func(d map[string]any) any { return d["documentNamespace"] },
+			date:   func(d map[string]any) any { return d["creationInfo"].(map[string]any)["created"] },
+		},
+		{
+			format: formats.CDX15JSON,
+			id:     func(d map[string]any) any { return d["serialNumber"] },
+			date:   func(d map[string]any) any { return d["metadata"].(map[string]any)["timestamp"] },
+		},
+	} {
+		t.Run(string(tc.format), func(t *testing.T) {
+			write := func(date time.Time) ([]byte, map[string]any) {
+				b := bytes.Buffer{}
+				if err := WriteNodeList(scopedNodeList(), "app", &date, nopCloser{&b}, tc.format); err != nil {
+					t.Fatal(err)
+				}
+				doc := map[string]any{}
+				if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+					t.Fatal(err)
+				}
+				return b.Bytes(), doc
+			}
+
+			first, doc := write(date)
+			second, _ := write(date)
+			if !bytes.Equal(first, second) {
+				t.Errorf("documents written from the same node list differ")
+			}
+			if got := tc.date(doc); got != "2024-06-01T12:00:00Z" {
+				t.Errorf("date: got %v", got)
+			}
+
+			_, later := write(date.Add(time.Hour))
+			if tc.id(doc) == tc.id(later) {
+				t.Errorf("documents with different contents share ID %v", tc.id(doc))
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package sbom
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/protobom/protobom/pkg/formats"
+	"github.com/protobom/protobom/pkg/native"
+	"github.com/protobom/protobom/pkg/sbom"
+	"github.com/protobom/protobom/pkg/writer"
+)
+
+// renderIndent is the indentation of the rendered documents, the protobom
+// writer default
+const renderIndent = 4
+
+// writeNative serializes doc to the native document of format, passes it to
+// edit and renders the result to w. The protobom serializers are called
+// directly, the writer registry is left as is.
+func writeNative(doc *sbom.Document, format formats.Format, w io.Writer, edit func(nativeDoc interface{}) error) error {
+	serializer, err := writer.GetFormatSerializer(format)
+	if err != nil {
+		return fmt.Errorf("getting serializer: %w", err)
+	}
+
+	nativeDoc, err := serializer.Serialize(doc, &native.SerializeOptions{}, nil)
+	if err != nil {
+		return fmt.Errorf("serializing SBOM to native format: %w", err)
+	}
+	if err := edit(nativeDoc); err != nil {
+		return err
+	}
+
+	if err := serializer.Render(nativeDoc, w, &native.RenderOptions{Indent: renderIndent}, nil); err != nil {
+		return fmt.Errorf("rendering SBOM: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package sbom
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	"github.com/protobom/protobom/pkg/reader"
+	"github.com/protobom/protobom/pkg/sbom"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// intotoAlgorithms maps the protobom hash algorithms to the in-toto digest
+// algorithm names
+var intotoAlgorithms = map[sbom.HashAlgorithm]string{
+	sbom.HashAlgorithm_MD5:      "md5",
+	sbom.HashAlgorithm_SHA1:     "sha1",
+	sbom.HashAlgorithm_SHA224:   "sha224",
+	sbom.HashAlgorithm_SHA256:   "sha256",
+	sbom.HashAlgorithm_SHA384:   "sha384",
+	sbom.HashAlgorithm_SHA512:   "sha512",
+	sbom.HashAlgorithm_SHA3_256: "sha3_256",
+	sbom.HashAlgorithm_SHA3_384: "sha3_384",
+	sbom.HashAlgorithm_SHA3_512: "sha3_512",
+}
+
+// ParseSBOM parses an SBOM and checks it describes at least one package
+// (its root elements)
+func ParseSBOM(f io.ReadSeeker) (*sbom.Document, error) {
+	r := reader.New()
+	doc, err := r.ParseStream(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SBOM: %w", err)
+	}
+	if len(doc.GetNodeList().GetRootNodes()) == 0 {
+		return nil, fmt.Errorf("SBOM does not describe any packages")
+	}
+	return doc, nil
+}
+
+// NodeSubject returns an in-toto subject for the node, named after the
+// package and with its hashes as digests. Hashes of algorithms unknown to
+// in-toto are skipped. It returns nil if the node has no usable hashes.
+func NodeSubject(n *sbom.Node) *intotov1.ResourceDescriptor {
+	digest := map[string]string{}
+	for algo, value := range n.GetHashes() {
+		if name, ok := intotoAlgorithms[sbom.HashAlgorithm(algo)]; ok && value != "" {
+			digest[name] = value
+		}
+	}
+	if len(digest) == 0 {
+		return nil
+	}
+	name := n.GetName()
+	if n.GetVersion() != "" {
+		name = fmt.Sprintf("%s@%s", name, n.GetVersion())
+	}
+	return &intotov1.ResourceDescriptor{Name: name, Digest: digest}
+}
+
+// NodePackageInfo returns the identity of the package in the node, to
+// record it in the predicate metadata
+func NodePackageInfo(n *sbom.Node) trusty.PackageInfo {
+	info := trusty.PackageInfo{
+		Package:     n.GetName(),
+		Version:     n.GetVersion(),
+		Identifiers: map[string]string{},
+	}
+	if purl := string(n.Purl()); purl != "" {
+		info.Identifiers["purl"] = purl
+		if e := purlToEcosystem(purl); e != 0 {
+			info.Ecosystem = e.AsString()
+		}
+	}
+	return info
+}
+
+// Ecosystems returns the sorted list of the Trusty ecosystems of the
+// packages in the node list
+func Ecosystems(nl *sbom.NodeList) []string {
+	seen := map[string]struct{}{}
+	for _, n := range nl.GetNodes() {
+		if e := purlToEcosystem(string(n.Purl())); e != 0 {
+			seen[e.AsString()] = struct{}{}
+		}
+	}
+	ret := []string{}
+	for e := range seen {
+		ret = append(ret, e)
+	}
+	sort.Strings(ret)
+	return ret
+}
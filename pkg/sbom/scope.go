@@ -0,0 +1,135 @@
+package sbom
+
+import (
+	"fmt"
+
+	"github.com/protobom/protobom/pkg/sbom"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// Scope filters accepted by the scorer
+const (
+	ScopeAll     = "all"
+	ScopeRuntime = "runtime"
+	ScopeDev     = "dev"
+)
+
+// ScopeFilters lists the valid values of the scorer scope option
+var ScopeFilters = []string{ScopeAll, ScopeRuntime, ScopeDev}
+
+// ValidateScopeFilter returns an error if the scope filter is not valid
+func ValidateScopeFilter(filter string) error {
+	for _, f := range ScopeFilters {
+		if f == filter {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid scope %q, valid scopes are %v", filter, ScopeFilters)
+}
+
+// ScopeIncluded returns true if a package in scope passes the filter.
+// Packages of unknown scope are considered runtime dependencies.
+func ScopeIncluded(filter string, scope trusty.Scope) bool {
+	switch filter {
+	case ScopeRuntime:
+		return !scope.IsDev()
+	case ScopeDev:
+		return scope.IsDev()
+	default:
+		return true
+	}
+}
+
+// FilterScope returns a copy of the node list with only the packages whose
+// scope passes the filter, as selected by the scorer. Nodes without a purl,
+// like the component and ecosystem roots, are kept along with the edges
+// among the nodes left. When the filter passes all scopes, the node list is
+// returned as is.
+func FilterScope(nl *sbom.NodeList, filter string) *sbom.NodeList {
+	if filter == "" || filter == ScopeAll {
+		return nl
+	}
+	scopes := NodeScopes(nl)
+	keep := map[string]struct{}{}
+	for _, id := range nl.RootElements {
+		keep[id] = struct{}{}
+	}
+
+	ret := sbom.NewNodeList()
+	for _, n := range nl.Nodes {
+		_, root := keep[n.Id]
+		if root || n.Purl() == "" || ScopeIncluded(filter, scopes[n.Id]) {
+			keep[n.Id] = struct{}{}
+			ret.Nodes = append(ret.Nodes, n)
+		}
+	}
+	for _, e := range nl.Edges {
+		if _, ok := keep[e.From]; !ok {
+			continue
+		}
+		to := []string{}
+		for _, id := range e.To {
+			if _, ok := keep[id]; ok {
+				to = append(to, id)
+			}
+		}
+		if len(to) > 0 {
+			ret.Edges = append(ret.Edges, &sbom.Edge{Type: e.Type, From: e.From, To: to})
+		}
+	}
+	ret.RootElements = append(ret.RootElements, nl.RootElements...)
+	return ret
+}
+
+// NodeScopes computes the scope of each node in the node list by walking
+// the graph from the root elements. Edges downgrade the scope of the nodes
+// they reach (a runtime dependency of a dev dependency is a dev dependency)
+// and when a node is reachable in more than one scope, the most critical
+// one wins. Nodes not reachable from the roots are not in the map.
+func NodeScopes(nl *sbom.NodeList) map[string]trusty.Scope {
+	scopes := map[string]trusty.Scope{}
+	queue := []string{}
+	for _, id := range nl.RootElements {
+		scopes[id] = trusty.ScopeRuntime
+		queue = append(queue, id)
+	}
+
+	// Index the dependencies of each node with the scope of the edge
+	// that records them
+	type dependency struct {
+		id    string
+		scope trusty.Scope
+	}
+	deps := map[string][]dependency{}
+	for _, e := range nl.Edges {
+		edgeScope, ok := trusty.EdgeScope(e.Type)
+		if !ok {
+			continue
+		}
+		for _, to := range e.To {
+			if trusty.DependencyOfEdge(e.Type) {
+				deps[to] = append(deps[to], dependency{id: e.From, scope: edgeScope})
+			} else {
+				deps[e.From] = append(deps[e.From], dependency{id: to, scope: edgeScope})
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, d := range deps[id] {
+			scope := scopes[id]
+			if d.scope.Rank() > scope.Rank() {
+				scope = d.scope
+			}
+			if current, ok := scopes[d.id]; ok && current.Rank() <= scope.Rank() {
+				continue
+			}
+			scopes[d.id] = scope
+			queue = append(queue, d.id)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,78 @@
+package sbom
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/protobom/protobom/pkg/sbom"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// scopedNodeList returns a node list where the project requires a at
+// runtime and d as a development dependency, both depend on b.
+func scopedNodeList() *sbom.NodeList {
+	nl := sbom.NewNodeList()
+	root := &sbom.Node{Id: "root", Name: "app"}
+	nl.AddRootNode(root)
+	nl.RelateNodeAtID(&sbom.Node{Id: "root-npm", Name: "npm"}, "root", sbom.Edge_dependsOn)
+	for _, name := range []string{"a", "b", "d"} {
+		nl.AddNode(&sbom.Node{
+			Id:          name,
+			Name:        name,
+			Identifiers: map[int32]string{int32(sbom.SoftwareIdentifierType_PURL): "pkg:npm/" + name + "@1.0.0"},
+		})
+	}
+	addDependency := func(parent, dep string, scope trusty.Scope) {
+		t := scope.EdgeType()
+		if trusty.DependencyOfEdge(t) {
+			nl.AddEdge(&sbom.Edge{Type: t, From: dep, To: []string{parent}})
+			return
+		}
+		nl.AddEdge(&sbom.Edge{Type: t, From: parent, To: []string{dep}})
+	}
+	addDependency("root-npm", "a", trusty.ScopeRuntime)
+	addDependency("root-npm", "d", trusty.ScopeDev)
+	addDependency("d", "b", trusty.ScopeRuntime)
+	return nl
+}
+
+func TestFilterScope(t *testing.T) {
+	for _, tc := range []struct {
+		filter string
+		nodes  []string
+	}{
+		{ScopeAll, []string{"a", "b", "d", "root", "root-npm"}},
+		{ScopeRuntime, []string{"a", "root", "root-npm"}},
+		{ScopeDev, []string{"b", "d", "root", "root-npm"}},
+	} {
+		t.Run(tc.filter, func(t *testing.T) {
+			nl := FilterScope(scopedNodeList(), tc.filter)
+			nodes := []string{}
+			for _, n := range nl.Nodes {
+				nodes = append(nodes, n.Id)
+			}
+			sort.Strings(nodes)
+			if !reflect.DeepEqual(nodes, tc.nodes) {
+				t.Errorf("nodes: got %v, want %v", nodes, tc.nodes)
+			}
+			if !reflect.DeepEqual(nl.RootElements, []string{"root"}) {
+				t.Errorf("root elements: got %v", nl.RootElements)
+			}
+
+			// Edges only point to the nodes left
+			kept := map[string]struct{}{}
+			for _, id := range nodes {
+				kept[id] = struct{}{}
+			}
+			for _, e := range nl.Edges {
+				for _, id := range append([]string{e.From}, e.To...) {
+					if _, ok := kept[id]; !ok {
+						t.Errorf("edge %s references filtered node %s", e.Type, id)
+					}
+				}
+			}
+		})
+	}
+}
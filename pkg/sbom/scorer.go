@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/anchore/packageurl-go"
 	"github.com/protobom/protobom/pkg/reader"
@@ -35,7 +36,20 @@ func NewScorer() *Scorer {
 }
 
 type Scorer struct {
-	trusty client.Trusty
+	Options Options
+	trusty  client.Trusty
+}
+
+// Endpoint returns the URL of the Trusty API the scorer reads from
+func (s *Scorer) Endpoint() string {
+	return s.trusty.Options.BaseURL
+}
+
+// Options controls which packages are scored
+type Options struct {
+	// Scope filters the packages by dependency scope: runtime, dev or all.
+	// When blank, all packages are scored.
+	Scope string
 }
 
 func (s *Scorer) ScoreSBOM(ctx context.Context, f io.ReadSeeker) ([]trusty.PackageScore, error) {
@@ -44,7 +58,11 @@ func (s *Scorer) ScoreSBOM(ctx context.Context, f io.ReadSeeker) ([]trusty.Packa
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse file")
 	}
+	return s.ScoreDocument(ctx, doc)
+}
 
+// ScoreDocument scores the packages in a parsed SBOM document
+func (s *Scorer) ScoreDocument(ctx context.Context, doc *sbom.Document) ([]trusty.PackageScore, error) {
 	if len(doc.NodeList.RootElements) == 0 {
 		return nil, fmt.Errorf("SBOM has no top evel elements")
 	}
@@ -62,10 +80,17 @@ func (s *Scorer) ScoreSBOM(ctx context.Context, f io.ReadSeeker) ([]trusty.Packa
 		}
 	}
 
-	return s.ScoreNodeList(ctx, n2)
+	return s.scoreNodes(ctx, n2, NodeScopes(doc.NodeList))
 }
 
+// ScoreNodeList scores the packages in a node list. The scope of each
+// package is computed from the node list graph.
 func (s *Scorer) ScoreNodeList(ctx context.Context, nl *sbom.NodeList) ([]trusty.PackageScore, error) {
+	return s.scoreNodes(ctx, nl, NodeScopes(nl))
+}
+
+// scoreNodes scores the nodes in a node list that pass the scope filter
+func (s *Scorer) scoreNodes(ctx context.Context, nl *sbom.NodeList, scopes map[string]trusty.Scope) ([]trusty.PackageScore, error) {
 	// Index the top level IDs
 	tlID := map[string]struct{}{}
 	for _, i := range nl.RootElements {
@@ -73,12 +98,15 @@ func (s *Scorer) ScoreNodeList(ctx context.Context, nl *sbom.NodeList) ([]trusty
 	}
 	scores := []trusty.PackageScore{}
 	fmt.Fprintf(os.Stderr, "Scoring %d dependencies", len(nl.Nodes))
-	defer fmt.Println("")
+	defer fmt.Fprintln(os.Stderr, "")
 	for _, n := range nl.Nodes {
 		fmt.Fprint(os.Stderr, ".")
 		if _, ok := tlID[n.Id]; ok {
 			continue
 		}
+		if !ScopeIncluded(s.Options.Scope, scopes[n.Id]) {
+			continue
+		}
 		score, err := s.ScoreNode(ctx, n)
 		if score == nil {
 			continue
@@ -91,6 +119,7 @@ func (s *Scorer) ScoreNodeList(ctx context.Context, nl *sbom.NodeList) ([]trusty
 			}
 			return nil, fmt.Errorf("fetching data from trusty: %w", err)
 		}
+		score.Scope = scopes[n.Id]
 		scores = append(scores, *score)
 	}
 	return scores, nil
@@ -134,6 +163,14 @@ func (s *Scorer) ScoreNode(ctx context.Context, n *sbom.Node) (*trusty.PackageSc
 		return &trusty.PackageScore{},
 			TrustyAPIError{fmt.Errorf("calling trusty api to score %q: %w", n.Purl(), err)}
 	}
+	retrieved := time.Now()
+
+	// Packages Trusty has no data on are recorded as unscored, with a
+	// zero score
+	score := 0.0
+	if res.Summary.Score != nil {
+		score = *res.Summary.Score
+	}
 
 	logrus.Debugf("Scored %s:%s@%s", purl.Type, name, purl.Version)
 
@@ -162,11 +199,13 @@ func (s *Scorer) ScoreNode(ctx context.Context, n *sbom.Node) (*trusty.PackageSc
 			Identifiers: ids,
 			Ecosystem:   ecoLabel,
 		},
-		Score: *res.Summary.Score,
+		Score: score,
 		// ActivityScore:   res.Activity.Score,
 		ProvenanceScore: res.Provenance.Score,
 		Details:         res.Summary.Description,
 		Malicious:       res.PackageData.Malicious != nil,
 		Deprecated:      res.PackageData.Deprecated,
+		Retrieved:       &retrieved,
+		Unscored:        res.Summary.Score == nil,
 	}, nil
 }
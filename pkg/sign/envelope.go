@@ -0,0 +1,42 @@
+package sign
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+// Signer signs the pre-authentication encoding (PAE) of DSSE envelopes.
+// KeySigner implements it for local keys, other signers (eg KMS backed)
+// can be plugged in to sign envelopes.
+type Signer interface {
+	// KeyID identifies the key in the envelope signatures, it can be empty
+	KeyID() string
+	// Sign returns the raw signature of data
+	Sign(ctx context.Context, data []byte) ([]byte, error)
+}
+
+// Envelope wraps payload in a DSSE envelope of payloadType with one
+// signature from each of the signers. Without signers the envelope is
+// returned unsigned.
+func Envelope(ctx context.Context, payloadType string, payload []byte, signers ...Signer) (*dsse.Envelope, error) {
+	env := &dsse.Envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []dsse.Signature{},
+	}
+	pae := dsse.PAE(payloadType, payload)
+	for _, s := range signers {
+		sig, err := s.Sign(ctx, pae)
+		if err != nil {
+			return nil, fmt.Errorf("signing envelope: %w", err)
+		}
+		env.Signatures = append(env.Signatures, dsse.Signature{
+			KeyID: s.KeyID(),
+			Sig:   base64.StdEncoding.EncodeToString(sig),
+		})
+	}
+	return env, nil
+}
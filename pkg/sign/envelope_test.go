@@ -0,0 +1,74 @@
+package sign
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+// fakeSigner returns the pre-authentication encoding it signs, prefixed
+// with its key id
+type fakeSigner struct {
+	keyID string
+	err   error
+}
+
+func (s *fakeSigner) KeyID() string { return s.keyID }
+
+func (s *fakeSigner) Sign(_ context.Context, data []byte) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return append([]byte(s.keyID+":"), data...), nil
+}
+
+func TestEnvelope(t *testing.T) {
+	payload := []byte(`{"_type":"test"}`)
+	pae := dsse.PAE(PayloadType, payload)
+
+	for _, tc := range []struct {
+		name    string
+		signers []Signer
+		keyIDs  []string
+		err     bool
+	}{
+		{"unsigned", nil, []string{}, false},
+		{"one", []Signer{&fakeSigner{keyID: "a"}}, []string{"a"}, false},
+		{"several", []Signer{&fakeSigner{keyID: "a"}, &fakeSigner{keyID: "b"}}, []string{"a", "b"}, false},
+		{"failing", []Signer{&fakeSigner{keyID: "a"}, &fakeSigner{err: errors.New("boom")}}, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env, err := Envelope(context.Background(), PayloadType, payload, tc.signers...)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("creating envelope: %v", err)
+			}
+			if env.PayloadType != PayloadType {
+				t.Errorf("expected payload type %q, got %q", PayloadType, env.PayloadType)
+			}
+			if env.Payload != base64.StdEncoding.EncodeToString(payload) {
+				t.Errorf("payload is not the base64 encoded statement: %q", env.Payload)
+			}
+			if env.Signatures == nil || len(env.Signatures) != len(tc.keyIDs) {
+				t.Fatalf("expected %d signatures, got %v", len(tc.keyIDs), env.Signatures)
+			}
+			for i, s := range env.Signatures {
+				sig, err := base64.StdEncoding.DecodeString(s.Sig)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if s.KeyID != tc.keyIDs[i] || string(sig) != tc.keyIDs[i]+":"+string(pae) {
+					t.Errorf("signature %d does not sign the PAE with %s: %v", i, tc.keyIDs[i], s)
+				}
+			}
+		})
+	}
+}
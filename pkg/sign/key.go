@@ -0,0 +1,130 @@
+// Package sign signs Trusty attestations with local keys or sigstore
+// certificates, wrapping them in DSSE envelopes and sigstore bundles
+package sign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	protodsse "github.com/sigstore/protobuf-specs/gen/pb-go/dsse"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"golang.org/x/term"
+)
+
+// PayloadType is the DSSE payload type of in-toto statements
+const PayloadType = "application/vnd.in-toto+json"
+
+// BundleMediaType is the media type of the bundles written
+const BundleMediaType = "application/vnd.dev.sigstore.bundle+json;version=0.2"
+
+// PasswordEnvVar is the environment variable read to decrypt private keys,
+// it is the same one cosign uses
+const PasswordEnvVar = "COSIGN_PASSWORD"
+
+// KeySigner signs attestations with a private key read from a file
+type KeySigner struct {
+	signer signature.SignerVerifier
+	public crypto.PublicKey
+	keyID  string
+}
+
+// LoadKey reads a PEM encoded private key. ECDSA, ed25519 and RSA keys are
+// supported, in PKCS8, EC or PKCS1 blocks, or encrypted in the cosign and
+// sigstore formats. Encrypted keys are decrypted with the password from
+// COSIGN_PASSWORD or, if unset, read from the terminal.
+func LoadKey(path string) (*KeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+	priv, err := cryptoutils.UnmarshalPEMToPrivateKey(data, readPassword)
+	if err != nil {
+		return nil, fmt.Errorf("decoding private key: %w", err)
+	}
+	sv, err := signature.LoadSignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("loading signer: %w", err)
+	}
+	pub, err := sv.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	keyID, err := dsse.SHA256KeyID(pub)
+	if err != nil {
+		return nil, fmt.Errorf("computing key id: %w", err)
+	}
+	return &KeySigner{signer: sv, public: pub, keyID: keyID}, nil
+}
+
+// readPassword returns the password to decrypt private keys
+func readPassword(bool) ([]byte, error) {
+	if pw, ok := os.LookupEnv(PasswordEnvVar); ok {
+		return []byte(pw), nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("key is encrypted, set the password in %s", PasswordEnvVar)
+	}
+	fmt.Fprint(os.Stderr, "Enter password for private key: ")
+	pw, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("reading password: %w", err)
+	}
+	return pw, nil
+}
+
+// KeyID returns the sha256 digest of the public key, recorded in signatures
+func (k *KeySigner) KeyID() string {
+	return k.keyID
+}
+
+// Sign signs data with the private key
+func (k *KeySigner) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	return k.signer.SignMessage(bytes.NewReader(data), options.WithContext(ctx))
+}
+
+// Bundle wraps an envelope signed with a key in a sigstore bundle whose
+// verification material is a hint to the signing public key. The bundle
+// carries no certificate or transparency log entries, it is verified with
+// the public key of the signer.
+func Bundle(env *dsse.Envelope) (*protobundle.Bundle, error) {
+	if len(env.Signatures) != 1 {
+		return nil, fmt.Errorf("bundles carry exactly one signature, envelope has %d", len(env.Signatures))
+	}
+	payload, err := env.DecodeB64Payload()
+	if err != nil {
+		return nil, fmt.Errorf("decoding envelope payload: %w", err)
+	}
+	sigs := []*protodsse.Signature{}
+	for _, s := range env.Signatures {
+		raw, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			return nil, fmt.Errorf("decoding signature: %w", err)
+		}
+		sigs = append(sigs, &protodsse.Signature{Sig: raw, Keyid: s.KeyID})
+	}
+	return &protobundle.Bundle{
+		MediaType: BundleMediaType,
+		VerificationMaterial: &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_PublicKey{
+				PublicKey: &protocommon.PublicKeyIdentifier{Hint: env.Signatures[0].KeyID},
+			},
+		},
+		Content: &protobundle.Bundle_DsseEnvelope{
+			DsseEnvelope: &protodsse.Envelope{
+				Payload:     payload,
+				PayloadType: env.PayloadType,
+				Signatures:  sigs,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,154 @@
+package sign
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
+)
+
+// writeKey writes a private key to a PEM file in a temporary directory
+func writeKey(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// verifyEnvelope checks the envelope signature with pub
+func verifyEnvelope(t *testing.T, env *dsse.Envelope, pub crypto.PublicKey) error {
+	t.Helper()
+	v, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := dsse.NewEnvelopeVerifier(&sigdsse.VerifierAdapter{SignatureVerifier: v, Pub: pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ev.Verify(context.Background(), env)
+	return err
+}
+
+func TestLoadKeyAndSign(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecSEC1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPKCS8, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		blockType string
+		der       []byte
+		pub       crypto.PublicKey
+	}{
+		{"ecdsa-pkcs8", "PRIVATE KEY", ecPKCS8, &ecKey.PublicKey},
+		{"ecdsa-sec1", "EC PRIVATE KEY", ecSEC1, &ecKey.PublicKey},
+		{"ed25519", "PRIVATE KEY", edPKCS8, edPub},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := LoadKey(writeKey(t, tc.blockType, tc.der))
+			if err != nil {
+				t.Fatalf("loading key: %v", err)
+			}
+			keyID, err := dsse.SHA256KeyID(tc.pub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if k.KeyID() != keyID {
+				t.Errorf("expected key id %s, got %s", keyID, k.KeyID())
+			}
+
+			env, err := Envelope(context.Background(), PayloadType, []byte(`{"_type":"test"}`), k)
+			if err != nil {
+				t.Fatalf("signing envelope: %v", err)
+			}
+			if len(env.Signatures) != 1 || env.Signatures[0].KeyID != keyID {
+				t.Fatalf("unexpected signatures %v", env.Signatures)
+			}
+			if err := verifyEnvelope(t, env, tc.pub); err != nil {
+				t.Errorf("verifying envelope: %v", err)
+			}
+
+			env.Payload = base64.StdEncoding.EncodeToString([]byte(`{"_type":"tampered"}`))
+			if err := verifyEnvelope(t, env, tc.pub); err == nil {
+				t.Error("tampered envelope verified")
+			}
+		})
+	}
+}
+
+func TestBundle(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := LoadKey(writeKey(t, "PRIVATE KEY", der))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte(`{"_type":"test"}`)
+	env, err := Envelope(context.Background(), PayloadType, payload, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Bundle(env)
+	if err != nil {
+		t.Fatalf("creating bundle: %v", err)
+	}
+	if b.MediaType != BundleMediaType || b.GetVerificationMaterial().GetPublicKey().GetHint() != k.KeyID() {
+		t.Errorf("unexpected bundle %v", b)
+	}
+	// The bundle carries the raw payload and signature, not their base64
+	// encoding in the envelope
+	sig, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	de := b.GetDsseEnvelope()
+	if string(de.Payload) != string(payload) || string(de.Signatures[0].Sig) != string(sig) {
+		t.Errorf("bundle envelope does not match the signed one")
+	}
+
+	// Bundles carry a single signature
+	env.Signatures = append(env.Signatures, env.Signatures[0])
+	if _, err := Bundle(env); err == nil {
+		t.Error("expected an error bundling two signatures")
+	}
+}
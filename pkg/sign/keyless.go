@@ -0,0 +1,133 @@
+package sign
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/puerco/bind/pkg/bundle"
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+)
+
+// Keyless signs statement with a short lived sigstore certificate, running
+// the OIDC flow, records the signature in the transparency log and returns
+// it in a bundle.
+func Keyless(ctx context.Context, statement []byte) (*protobundle.Bundle, error) {
+	pb, err := bundle.NewSigner().SignAndBind(ctx, statement)
+	if err != nil {
+		return nil, fmt.Errorf("signing and binding attestation: %w", err)
+	}
+	if err := DecodeLegacyBundle(pb); err != nil {
+		return nil, fmt.Errorf("encoding bundle: %w", err)
+	}
+	return pb, nil
+}
+
+// DecodeLegacyBundle converts a bundle in the shape written by the keyless
+// signer, and by trusty attest --bundle before it was converted, to the one
+// the bundle spec expects: the certificates are PEM encoded (the chain in a
+// single entry), the log ids and inclusion proof hashes hex encoded and the
+// envelope signatures base64 encoded. Any field not encoded this way is an
+// error, the bundle is not converted partially.
+func DecodeLegacyBundle(pb *protobundle.Bundle) error {
+	sigs := [][]byte{}
+	for _, s := range pb.GetDsseEnvelope().GetSignatures() {
+		raw, err := base64.StdEncoding.DecodeString(string(s.Sig))
+		if err != nil {
+			return fmt.Errorf("decoding envelope signature: %w", err)
+		}
+		sigs = append(sigs, raw)
+	}
+
+	vm := pb.GetVerificationMaterial()
+	var chain []*protocommon.X509Certificate
+	if vm.GetX509CertificateChain() != nil {
+		chain = []*protocommon.X509Certificate{}
+		for _, c := range vm.GetX509CertificateChain().GetCertificates() {
+			certs, err := decodePEMCertificates(c.RawBytes)
+			if err != nil {
+				return err
+			}
+			chain = append(chain, certs...)
+		}
+	}
+	var cert *protocommon.X509Certificate
+	if vm.GetCertificate() != nil {
+		certs, err := decodePEMCertificates(vm.GetCertificate().RawBytes)
+		if err != nil {
+			return err
+		}
+		if len(certs) != 1 {
+			return fmt.Errorf("expected one certificate, found %d", len(certs))
+		}
+		cert = certs[0]
+	}
+
+	type hexField struct {
+		name string
+		dst  *[]byte
+	}
+	fields := []hexField{}
+	for _, e := range vm.GetTlogEntries() {
+		if e.LogId != nil {
+			fields = append(fields, hexField{"log id", &e.LogId.KeyId})
+		}
+		if p := e.GetInclusionProof(); p != nil {
+			fields = append(fields, hexField{"inclusion proof root hash", &p.RootHash})
+			for i := range p.Hashes {
+				fields = append(fields, hexField{"inclusion proof hash", &p.Hashes[i]})
+			}
+		}
+	}
+	hashes := make([][]byte, len(fields))
+	for i, f := range fields {
+		raw, err := hex.DecodeString(string(*f.dst))
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", f.name, err)
+		}
+		if len(raw) != sha256.Size {
+			return fmt.Errorf("decoding %s: expected a sha256 hash, got %d bytes", f.name, len(raw))
+		}
+		hashes[i] = raw
+	}
+
+	for i, s := range pb.GetDsseEnvelope().GetSignatures() {
+		s.Sig = sigs[i]
+	}
+	if chain != nil {
+		vm.GetX509CertificateChain().Certificates = chain
+	}
+	if cert != nil {
+		vm.GetCertificate().RawBytes = cert.RawBytes
+	}
+	for i, f := range fields {
+		*f.dst = hashes[i]
+	}
+	return nil
+}
+
+// decodePEMCertificates returns the DER certificates in a PEM encoded
+// chain. Empty entries hold no certificates.
+func decodePEMCertificates(data []byte) ([]*protocommon.X509Certificate, error) {
+	certs := []*protocommon.X509Certificate{}
+	rest := bytes.TrimSpace(data)
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("certificate is not PEM encoded")
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block %q in certificate chain", block.Type)
+		}
+		certs = append(certs, &protocommon.X509Certificate{RawBytes: block.Bytes})
+		rest = bytes.TrimSpace(rest)
+	}
+	return certs, nil
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LogFileName is the name of the log written when the store is a directory
+const LogFileName = "attestations.jsonl"
+
+// LocalStore keeps the entries in a local JSON lines file, one entry per
+// line. Entries are only ever appended to the file.
+type LocalStore struct {
+	path string
+}
+
+// NewLocal returns a store backed by the JSON lines file at path. When
+// path is a directory (or ends with a separator), the entries are kept in
+// attestations.jsonl inside it.
+func NewLocal(path string) *LocalStore {
+	if strings.HasSuffix(path, string(filepath.Separator)) {
+		return &LocalStore{path: filepath.Join(path, LogFileName)}
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return &LocalStore{path: filepath.Join(path, LogFileName)}
+	}
+	return &LocalStore{path: path}
+}
+
+// Path returns the path of the log file
+func (s *LocalStore) Path() string {
+	return s.path
+}
+
+// Append writes the entry at the end of the log. It returns ErrDuplicate
+// if an entry with the same ID is already stored. The log is locked while
+// checking for duplicates and writing, so concurrent appends don't
+// interleave or store the same attestation twice.
+func (s *LocalStore) Append(e *Entry) error {
+	if err := e.Verify(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating store directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer f.Close()
+	if err := lockFile(f, true); err != nil {
+		return fmt.Errorf("locking store: %w", err)
+	}
+
+	entries, err := s.decode(f, &Query{})
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		if entries[i].ID == e.ID {
+			return ErrDuplicate
+		}
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("appending to store: %w", err)
+	}
+	return nil
+}
+
+// List returns all the entries in the order they were appended
+func (s *LocalStore) List() ([]Entry, error) {
+	return s.Query(&Query{})
+}
+
+// Query returns the entries selected by the query in the order they were
+// appended
+func (s *LocalStore) Query(q *Query) ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening store: %w", err)
+	}
+	defer f.Close()
+	if err := lockFile(f, false); err != nil {
+		return nil, fmt.Errorf("locking store: %w", err)
+	}
+	return s.decode(f, q)
+}
+
+// decode reads the entries in the log selected by the query
+func (s *LocalStore) decode(r io.Reader, q *Query) ([]Entry, error) {
+	entries := []Entry{}
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		e := Entry{}
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decoding entry %d of %s: %w", i, s.path, err)
+		}
+		if q.Matches(&e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Get returns the entry whose ID is id or starts with it. The document
+// digest is checked before returning it. It returns an error wrapping
+// ErrNotFound if no entry matches.
+func (s *LocalStore) Get(id string) (*Entry, error) {
+	if id == "" {
+		return nil, errors.New("no entry id specified")
+	}
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Entry
+	for i := range entries {
+		if !strings.HasPrefix(entries[i].ID, strings.ToLower(id)) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("id %q matches more than one entry", id)
+		}
+		found = &entries[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err := found.Verify(); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
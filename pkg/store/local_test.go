@@ -0,0 +1,214 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// appendEntry creates the entry of a test document and appends it
+func appendEntry(t *testing.T, s *LocalStore, project, version string, days int) *Entry {
+	t.Helper()
+	e, err := NewEntry(testDocument(t, project, version, days))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(e); err != nil {
+		t.Fatalf("appending %s@%s: %v", project, version, err)
+	}
+	return e
+}
+
+func TestNewLocal(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		path string
+		log  string
+	}{
+		{dir, filepath.Join(dir, LogFileName)},
+		{filepath.Join(dir, "new") + string(filepath.Separator), filepath.Join(dir, "new", LogFileName)},
+		{filepath.Join(dir, "history.jsonl"), filepath.Join(dir, "history.jsonl")},
+	} {
+		if got := NewLocal(tc.path).Path(); got != tc.log {
+			t.Errorf("%s: expected log %s, got %s", tc.path, tc.log, got)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	s := NewLocal(filepath.Join(t.TempDir(), "store") + string(filepath.Separator))
+
+	entries, err := s.List()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty store, got %v %v", entries, err)
+	}
+
+	first := appendEntry(t, s, "app", "1.0.0", 1)
+	second := appendEntry(t, s, "app", "1.1.0", 2)
+	appendEntry(t, s, "lib", "0.1.0", 3)
+
+	if err := s.Append(first); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("expected a duplicate error, got %v", err)
+	}
+
+	entries, err = s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := []string{}
+	for _, e := range entries {
+		versions = append(versions, e.Project+"@"+e.Version)
+	}
+	if got := strings.Join(versions, " "); got != "app@1.0.0 app@1.1.0 lib@0.1.0" {
+		t.Errorf("unexpected entries %s", got)
+	}
+
+	got, err := s.Query(&Query{Digest: "sha256:" + testDigest("app-1.1.0.tar.gz")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != second.ID {
+		t.Errorf("expected the digest to select %s, got %v", second.ID, got)
+	}
+	got, err = s.Query(&Query{Project: "app", Since: &second.Date})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != second.ID {
+		t.Errorf("expected the project and date to select %s, got %v", second.ID, got)
+	}
+
+	e, err := s.Get(first.ID)
+	if err != nil || e.ID != first.ID {
+		t.Errorf("getting by id: %v %v", e, err)
+	}
+	e, err = s.Get(strings.ToUpper(first.ID[:12]))
+	if err != nil || e.ID != first.ID {
+		t.Errorf("getting by prefix: %v %v", e, err)
+	}
+	if _, err := s.Get(testDigest("missing")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+	if _, err := s.Get(""); err == nil {
+		t.Error("expected an error getting a blank id")
+	}
+}
+
+func TestLocalStoreAmbiguousPrefix(t *testing.T) {
+	s := NewLocal(filepath.Join(t.TempDir(), LogFileName))
+
+	// Append entries until two IDs share their first character
+	seen := map[byte]string{}
+	prefix := ""
+	for i := 0; prefix == ""; i++ {
+		e := appendEntry(t, s, "app", fmt.Sprintf("1.0.%d", i), i)
+		if _, ok := seen[e.ID[0]]; ok {
+			prefix = e.ID[:1]
+		}
+		seen[e.ID[0]] = e.ID
+	}
+
+	if _, err := s.Get(prefix); err == nil || !strings.Contains(err.Error(), "more than one entry") {
+		t.Errorf("expected an ambiguous id error, got %v", err)
+	}
+}
+
+func TestLocalStoreTampered(t *testing.T) {
+	s := NewLocal(filepath.Join(t.TempDir(), LogFileName))
+	e := appendEntry(t, s, "app", "1.0.0", 1)
+
+	data, err := os.ReadFile(s.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The document is embedded as JSON at the end of the log line, after
+	// the indexed fields
+	i := strings.LastIndex(string(data), `"version":"1.0.0"`)
+	if i == -1 || i < strings.Index(string(data), `"document"`) {
+		t.Fatal("version not found in the document")
+	}
+	tampered := string(data[:i]) + strings.Replace(string(data[i:]), "1.0.0", "6.6.6", 1)
+	if err := os.WriteFile(s.Path(), []byte(tampered), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Get(e.ID); err == nil || !strings.Contains(err.Error(), "does not match its digest") {
+		t.Errorf("expected the tampered entry to be rejected, got %v", err)
+	}
+}
+
+func TestLocalStoreConcurrentAppend(t *testing.T) {
+	s := NewLocal(filepath.Join(t.TempDir(), LogFileName))
+	e, err := NewEntry(testDocument(t, "app", "1.0.0", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const appenders = 32
+	errs := make([]error, appenders)
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < appenders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = NewLocal(s.Path()).Append(e)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	stored := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			stored++
+		case !errors.Is(err, ErrDuplicate):
+			t.Errorf("appending: %v", err)
+		}
+	}
+	entries, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored != 1 || len(entries) != 1 {
+		t.Errorf("expected the entry stored once, appended %d times, %d in the log", stored, len(entries))
+	}
+}
+
+func TestLocalStoreAppendWaitsForLock(t *testing.T) {
+	s := NewLocal(filepath.Join(t.TempDir(), LogFileName))
+	e, err := NewEntry(testDocument(t, "app", "1.0.0", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another writer holds the log
+	f, err := os.OpenFile(s.Path(), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := lockFile(f, true); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- s.Append(e) }()
+	select {
+	case err := <-done:
+		t.Fatalf("append did not wait for the lock: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	f.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("appending: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package store keeps an append-only history of Trusty attestations,
+// indexed by the digests of their subjects and their date
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// ErrDuplicate is returned when appending an attestation already stored
+var ErrDuplicate = errors.New("attestation already in the store")
+
+// ErrNotFound is returned when getting an attestation not in the store
+var ErrNotFound = errors.New("attestation not found in the store")
+
+// Store is an append-only collection of attestations. Entries are never
+// modified or removed once appended.
+type Store interface {
+	Append(*Entry) error
+	List() ([]Entry, error)
+	Get(id string) (*Entry, error)
+	Query(*Query) ([]Entry, error)
+}
+
+// Entry is an attestation document and the fields it is indexed by
+type Entry struct {
+	// ID is the sha256 digest of the compacted document
+	ID string `json:"id"`
+	// Date is the date recorded in the predicate
+	Date time.Time `json:"date"`
+	// Format is the wrapper of the predicate: bundle, envelope or statement
+	Format   string    `json:"format"`
+	Project  string    `json:"project,omitempty"`
+	Version  string    `json:"version,omitempty"`
+	Subjects []Subject `json:"subjects"`
+	// Document is the attestation as written by attest
+	Document json.RawMessage `json:"document"`
+}
+
+// Subject is an attestation subject, identified by its digests
+type Subject struct {
+	Name   string            `json:"name,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// NewEntry decodes an attestation document and returns its store entry.
+// Signatures are not verified, the document is stored as is.
+func NewEntry(doc []byte) (*Entry, error) {
+	st, format, err := trusty.Unwrap(doc)
+	if err != nil {
+		return nil, err
+	}
+	if format == trusty.WrapperPredicate {
+		return nil, errors.New("bare predicates have no subjects to index, store a statement")
+	}
+
+	compact := bytes.Buffer{}
+	if err := json.Compact(&compact, doc); err != nil {
+		return nil, fmt.Errorf("compacting document: %w", err)
+	}
+	digest := sha256.Sum256(compact.Bytes())
+
+	e := &Entry{
+		ID:       hex.EncodeToString(digest[:]),
+		Date:     time.Now().UTC(),
+		Format:   format,
+		Project:  st.Predicate.Metadata.Package,
+		Version:  st.Predicate.Metadata.Version,
+		Subjects: []Subject{},
+		Document: compact.Bytes(),
+	}
+	if d := st.Predicate.Metadata.Date; d != nil {
+		e.Date = d.UTC()
+	}
+	for _, s := range st.Subjects {
+		name := s.GetName()
+		if name == "" {
+			name = s.GetUri()
+		}
+		e.Subjects = append(e.Subjects, Subject{Name: name, Digest: s.GetDigest()})
+	}
+	return e, nil
+}
+
+// Verify checks the document was not modified since the entry was created
+func (e *Entry) Verify() error {
+	digest := sha256.Sum256(e.Document)
+	if hex.EncodeToString(digest[:]) != e.ID {
+		return fmt.Errorf("document of entry %s does not match its digest", e.ID)
+	}
+	return nil
+}
+
+// Query selects entries. Unset fields match every entry.
+type Query struct {
+	// Digest matches subjects by digest, either as algorithm:value or the
+	// bare value of any algorithm
+	Digest string
+	// Project matches the name of the attested package
+	Project string
+	// Since and Until bound the entry dates, both inclusive
+	Since *time.Time
+	Until *time.Time
+}
+
+// Matches returns true if the entry is selected by the query
+func (q *Query) Matches(e *Entry) bool {
+	if q.Project != "" && q.Project != e.Project {
+		return false
+	}
+	if q.Since != nil && e.Date.Before(*q.Since) {
+		return false
+	}
+	if q.Until != nil && e.Date.After(*q.Until) {
+		return false
+	}
+	if q.Digest == "" {
+		return true
+	}
+
+	algo, value, found := strings.Cut(q.Digest, ":")
+	if !found {
+		algo, value = "", q.Digest
+	}
+	for _, s := range e.Subjects {
+		for a, d := range s.Digest {
+			if (algo == "" || strings.EqualFold(algo, a)) && strings.EqualFold(value, d) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// testDate is the date of the test attestations
+var testDate = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testDigest returns the sha256 hex digest of s
+func testDigest(s string) string {
+	d := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(d[:])
+}
+
+// testDocument returns an attestation statement of a project version
+// about a subject named after them, dated days after testDate
+func testDocument(t *testing.T, project, version string, days int) []byte {
+	t.Helper()
+	date := testDate.AddDate(0, 0, days)
+	subject := fmt.Sprintf("%s-%s.tar.gz", project, version)
+	st, err := trusty.Attest(
+		[]*intotov1.ResourceDescriptor{{Name: subject, Digest: map[string]string{"sha256": testDigest(subject)}}},
+		&trusty.Predicate{
+			Metadata: trusty.Metadata{
+				Date:        &date,
+				PackageInfo: trusty.PackageInfo{Package: project, Version: version},
+			},
+			Packages: []trusty.PackageScore{},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := trusty.CanonicalJSON(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestNewEntry(t *testing.T) {
+	e, err := NewEntry(testDocument(t, "app", "1.0.0", 1))
+	if err != nil {
+		t.Fatalf("creating entry: %v", err)
+	}
+	if e.ID != testDigest(string(e.Document)) || strings.Contains(string(e.Document), "\n") {
+		t.Errorf("the id is not the digest of the compacted document")
+	}
+	if e.Format != trusty.WrapperStatement || e.Project != "app" || e.Version != "1.0.0" || !e.Date.Equal(testDate.AddDate(0, 0, 1)) {
+		t.Errorf("unexpected entry %s %s %s %s", e.Format, e.Project, e.Version, e.Date)
+	}
+	if len(e.Subjects) != 1 || e.Subjects[0].Name != "app-1.0.0.tar.gz" || e.Subjects[0].Digest["sha256"] != testDigest("app-1.0.0.tar.gz") {
+		t.Errorf("unexpected subjects %v", e.Subjects)
+	}
+	if err := e.Verify(); err != nil {
+		t.Errorf("verifying entry: %v", err)
+	}
+
+	e.Document = []byte(strings.Replace(string(e.Document), "1.0.0", "6.6.6", 1))
+	if err := e.Verify(); err == nil {
+		t.Error("tampered document verified")
+	}
+
+	if _, err := NewEntry([]byte(`{"metadata": {}, "packages": []}`)); err == nil {
+		t.Error("expected bare predicates to be rejected")
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	e, err := NewEntry(testDocument(t, "app", "1.0.0", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := testDigest("app-1.0.0.tar.gz")
+	before, after := testDate, testDate.AddDate(0, 0, 2)
+
+	for _, tc := range []struct {
+		name    string
+		query   Query
+		matches bool
+	}{
+		{"empty", Query{}, true},
+		{"digest", Query{Digest: "sha256:" + digest}, true},
+		{"bare-digest", Query{Digest: digest}, true},
+		{"digest-case", Query{Digest: "SHA256:" + strings.ToUpper(digest)}, true},
+		{"other-algorithm", Query{Digest: "sha512:" + digest}, false},
+		{"other-digest", Query{Digest: testDigest("other")}, false},
+		{"project", Query{Project: "app"}, true},
+		{"other-project", Query{Project: "lib"}, false},
+		{"since", Query{Since: &before}, true},
+		{"since-after", Query{Since: &after}, false},
+		{"until", Query{Until: &after}, true},
+		{"until-before", Query{Until: &before}, false},
+		{"inclusive", Query{Since: &e.Date, Until: &e.Date}, true},
+		{"all", Query{Digest: digest, Project: "app", Since: &before, Until: &after}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.query.Matches(e); got != tc.matches {
+				t.Errorf("expected match %v, got %v", tc.matches, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package trusty
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// SourceDateEpochEnvVar is the environment variable defined by the
+// reproducible builds project to fix the timestamps recorded in outputs
+const SourceDateEpochEnvVar = "SOURCE_DATE_EPOCH"
+
+// SourceDateEpoch returns the time set in SOURCE_DATE_EPOCH or nil if the
+// variable is not set
+func SourceDateEpoch() (*time.Time, error) {
+	v, ok := os.LookupEnv(SourceDateEpochEnvVar)
+	if !ok || v == "" {
+		return nil, nil
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, must be seconds since the epoch: %w", SourceDateEpochEnvVar, err)
+	}
+	t := time.Unix(secs, 0).UTC()
+	return &t, nil
+}
+
+// ParseTimestamp parses a timestamp expressed as seconds since the epoch
+// or as an RFC 3339 date
+func ParseTimestamp(s string) (*time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t := time.Unix(secs, 0).UTC()
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q, must be seconds since the epoch or RFC 3339", s)
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// SortPackages sorts the package scores canonically by ecosystem, name,
+// version, purl and scope
+func SortPackages(scores []PackageScore) {
+	slices.SortStableFunc(scores, func(a, b PackageScore) int {
+		return cmp.Or(
+			strings.Compare(a.Ecosystem, b.Ecosystem),
+			strings.Compare(a.Package, b.Package),
+			strings.Compare(a.Version, b.Version),
+			strings.Compare(a.Identifiers["purl"], b.Identifiers["purl"]),
+			strings.Compare(string(a.Scope), string(b.Scope)),
+		)
+	})
+}
+
+// CanonicalJSON encodes v in the canonical form used for all the documents
+// written: object keys sorted, two space indentation, no HTML escaping and
+// a trailing newline. Protocol buffer messages are encoded with protojson,
+// whose output is otherwise not stable across runs. Encoding the same value
+// always returns the same bytes.
+func CanonicalJSON(v any) ([]byte, error) {
+	var data []byte
+	var err error
+	if m, ok := v.(proto.Message); ok {
+		data, err = protojson.Marshal(m)
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encoding JSON: %w", err)
+	}
+
+	// Decoding to generic values sorts the object keys when encoding again,
+	// numbers are kept exactly as written.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("decoding JSON: %w", err)
+	}
+
+	b := bytes.Buffer{}
+	enc := json.NewEncoder(&b)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(generic); err != nil {
+		return nil, fmt.Errorf("encoding canonical JSON: %w", err)
+	}
+	return b.Bytes(), nil
+}
@@ -2,16 +2,76 @@ package trusty
 
 import "time"
 
-// TODO(puerco): Protobuf this
+// The predicate is also defined in protocol buffers in api/predicate.proto,
+// see ToProto and PredicateFromProto to convert between both.
+
+// RiskyScoreThreshold is the score at or below which a package is
+// considered risky
+const RiskyScoreThreshold = 5.0
 
 type Predicate struct {
-	Metadata Metadata       `json:"metadata"`
+	Metadata Metadata `json:"metadata"`
+	// Summary aggregates the package scores, see Summarize
+	Summary  *Summary       `json:"summary,omitempty"`
 	Packages []PackageScore `json:"packages"`
 }
 
 type Metadata struct {
 	Date        *time.Time `json:"date,omitempty"`
 	PackageInfo `json:"package,omitempty"`
+	Source      *SourceInfo `json:"source,omitempty"`
+	// Tool is the program that generated the predicate
+	Tool *ToolInfo `json:"tool,omitempty"`
+	// Endpoint is the URL of the Trusty API the scores were read from
+	Endpoint string `json:"endpoint,omitempty"`
+	// Input is what was scanned to find the packages
+	Input *InputInfo `json:"input,omitempty"`
+	// Ecosystems lists the package ecosystems found in the input
+	Ecosystems []string `json:"ecosystems,omitempty"`
+	// Options are the settings that selected the packages scored
+	Options *ScanOptions `json:"options,omitempty"`
+}
+
+// ToolInfo identifies the program that generated the predicate
+type ToolInfo struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Input types recorded in the predicate metadata
+const (
+	InputDirectory = "directory"
+	InputSBOM      = "sbom"
+)
+
+// InputInfo describes the input scanned for packages: a source directory
+// or an SBOM, identified by its digests. The path is relative to the root
+// of the git repository of the input.
+type InputInfo struct {
+	Type   string            `json:"type,omitempty"`
+	Path   string            `json:"path,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// ScanOptions records the options that selected the packages scored
+type ScanOptions struct {
+	// Scope is the dependency scope filter: runtime, dev or all
+	Scope string `json:"scope,omitempty"`
+	// Transients is true when transitive dependencies are scored
+	Transients bool `json:"transients,omitempty"`
+	// Recursive is true when manifests were read from the whole tree
+	Recursive bool     `json:"recursive,omitempty"`
+	Include   []string `json:"include,omitempty"`
+	Exclude   []string `json:"exclude,omitempty"`
+	// Revision is the git revision the manifests were read from
+	Revision string `json:"revision,omitempty"`
+}
+
+// SourceInfo identifies the revision of the source code that was attested
+type SourceInfo struct {
+	Repository string `json:"repository,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	Tag        string `json:"tag,omitempty"`
 }
 
 type PackageInfo struct {
@@ -24,9 +84,21 @@ type PackageInfo struct {
 type PackageScore struct {
 	PackageInfo
 	Score           float64        `json:"score"`
-	ActivityScore   float64        `json:"activity"`
+	ActivityScore   float64        `json:"activity,omitempty"` // not read from Trusty yet
 	ProvenanceScore float64        `json:"provenance"`
 	Details         map[string]any `json:"details"`
 	Malicious       bool           `json:"malicious"`
 	Deprecated      bool           `json:"deprecated"`
+	Scope           Scope          `json:"scope,omitempty"`
+	// Retrieved is the time the data was read from the Trusty API
+	Retrieved *time.Time `json:"retrieved,omitempty"`
+	// Unscored is set when Trusty has no score for the package, Score is
+	// zero then. A zero score without it is a score like any other.
+	Unscored bool `json:"unscored,omitempty"`
+}
+
+// IsRisky returns true if the package score is at or below the risk
+// threshold. Unscored packages are not risky.
+func (ps *PackageScore) IsRisky() bool {
+	return !ps.Unscored && ps.Score <= RiskyScoreThreshold
 }
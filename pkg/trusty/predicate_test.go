@@ -0,0 +1,23 @@
+package trusty
+
+import "testing"
+
+func TestIsRisky(t *testing.T) {
+	for _, tc := range []struct {
+		score    float64
+		unscored bool
+		risky    bool
+	}{
+		{0, true, false},
+		{0, false, true},
+		{0.5, false, true},
+		{RiskyScoreThreshold, false, true},
+		{5.1, false, false},
+		{10, false, false},
+	} {
+		ps := &PackageScore{Score: tc.score, Unscored: tc.unscored}
+		if got := ps.IsRisky(); got != tc.risky {
+			t.Errorf("score %v (unscored %v): got risky %v, want %v", tc.score, tc.unscored, got, tc.risky)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// Package predicatepb contains the Go bindings generated from the protocol
+// buffers definition of the Trusty predicate in api/predicate.proto. Use the
+// protojson package to read and write predicates in their JSON encoding.
+package predicatepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative -I ../../../api predicate.proto
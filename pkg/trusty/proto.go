@@ -0,0 +1,213 @@
+package trusty
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty/predicatepb"
+)
+
+// ToProto converts the predicate to its protocol buffers message
+func (p *Predicate) ToProto() (*predicatepb.Predicate, error) {
+	pb := &predicatepb.Predicate{
+		Metadata: &predicatepb.Metadata{
+			Package: &predicatepb.PackageInfo{
+				Package:     p.Metadata.PackageInfo.Package,
+				Version:     p.Metadata.PackageInfo.Version,
+				Identifiers: p.Metadata.PackageInfo.Identifiers,
+				Ecosystem:   p.Metadata.PackageInfo.Ecosystem,
+			},
+		},
+		Packages: []*predicatepb.PackageScore{},
+	}
+	if p.Metadata.Date != nil {
+		pb.Metadata.Date = timestamppb.New(*p.Metadata.Date)
+	}
+	if s := p.Metadata.Source; s != nil {
+		pb.Metadata.Source = &predicatepb.SourceInfo{
+			Repository: s.Repository,
+			Commit:     s.Commit,
+			Tag:        s.Tag,
+		}
+	}
+	if t := p.Metadata.Tool; t != nil {
+		pb.Metadata.Tool = &predicatepb.ToolInfo{Name: t.Name, Version: t.Version}
+	}
+	pb.Metadata.Endpoint = p.Metadata.Endpoint
+	if in := p.Metadata.Input; in != nil {
+		pb.Metadata.Input = &predicatepb.InputInfo{Type: in.Type, Path: in.Path, Digest: in.Digest}
+	}
+	pb.Metadata.Ecosystems = p.Metadata.Ecosystems
+	if o := p.Metadata.Options; o != nil {
+		pb.Metadata.Options = &predicatepb.ScanOptions{
+			Scope:      o.Scope,
+			Transients: o.Transients,
+			Recursive:  o.Recursive,
+			Include:    o.Include,
+			Exclude:    o.Exclude,
+			Revision:   o.Revision,
+		}
+	}
+
+	if s := p.Summary; s != nil {
+		pb.Summary = &predicatepb.Summary{
+			Total: int32(s.Total),
+			ScoreBands: &predicatepb.ScoreBands{
+				Low:    int32(s.ScoreBands.Low),
+				Medium: int32(s.ScoreBands.Medium),
+				High:   int32(s.ScoreBands.High),
+			},
+			Malicious:   int32(s.Malicious),
+			Deprecated:  int32(s.Deprecated),
+			Unscored:    int32(s.Unscored),
+			MinScore:    s.MinScore,
+			MedianScore: s.MedianScore,
+		}
+		if s.Ecosystems != nil {
+			pb.Summary.Ecosystems = map[string]int32{}
+			for e, n := range s.Ecosystems {
+				pb.Summary.Ecosystems[e] = int32(n)
+			}
+		}
+	}
+
+	for i := range p.Packages {
+		ps := &p.Packages[i]
+		score := &predicatepb.PackageScore{
+			Package:         ps.Package,
+			Version:         ps.Version,
+			Identifiers:     ps.Identifiers,
+			Ecosystem:       ps.Ecosystem,
+			Score:           ps.Score,
+			ActivityScore:   ps.ActivityScore,
+			ProvenanceScore: ps.ProvenanceScore,
+			Malicious:       ps.Malicious,
+			Deprecated:      ps.Deprecated,
+			Scope:           string(ps.Scope),
+			Unscored:        ps.Unscored,
+		}
+		if ps.Retrieved != nil {
+			score.Retrieved = timestamppb.New(*ps.Retrieved)
+		}
+		if ps.Details != nil {
+			details, err := detailsToStruct(ps.Details)
+			if err != nil {
+				return nil, fmt.Errorf("converting details of %s: %w", ps.Package, err)
+			}
+			score.Details = details
+		}
+		pb.Packages = append(pb.Packages, score)
+	}
+	return pb, nil
+}
+
+// PredicateFromProto converts a predicate protocol buffers message to the
+// predicate struct
+func PredicateFromProto(pb *predicatepb.Predicate) *Predicate {
+	p := &Predicate{Packages: []PackageScore{}}
+	if md := pb.GetMetadata(); md != nil {
+		if md.Date != nil {
+			t := md.Date.AsTime()
+			p.Metadata.Date = &t
+		}
+		p.Metadata.PackageInfo = PackageInfo{
+			Package:     md.GetPackage().GetPackage(),
+			Version:     md.GetPackage().GetVersion(),
+			Identifiers: md.GetPackage().GetIdentifiers(),
+			Ecosystem:   md.GetPackage().GetEcosystem(),
+		}
+		if s := md.GetSource(); s != nil {
+			p.Metadata.Source = &SourceInfo{
+				Repository: s.Repository,
+				Commit:     s.Commit,
+				Tag:        s.Tag,
+			}
+		}
+		if t := md.GetTool(); t != nil {
+			p.Metadata.Tool = &ToolInfo{Name: t.Name, Version: t.Version}
+		}
+		p.Metadata.Endpoint = md.GetEndpoint()
+		if in := md.GetInput(); in != nil {
+			p.Metadata.Input = &InputInfo{Type: in.Type, Path: in.Path, Digest: in.Digest}
+		}
+		p.Metadata.Ecosystems = md.GetEcosystems()
+		if o := md.GetOptions(); o != nil {
+			p.Metadata.Options = &ScanOptions{
+				Scope:      o.Scope,
+				Transients: o.Transients,
+				Recursive:  o.Recursive,
+				Include:    o.Include,
+				Exclude:    o.Exclude,
+				Revision:   o.Revision,
+			}
+		}
+	}
+
+	if s := pb.GetSummary(); s != nil {
+		p.Summary = &Summary{
+			Total: int(s.Total),
+			ScoreBands: ScoreBands{
+				Low:    int(s.GetScoreBands().GetLow()),
+				Medium: int(s.GetScoreBands().GetMedium()),
+				High:   int(s.GetScoreBands().GetHigh()),
+			},
+			Malicious:   int(s.Malicious),
+			Deprecated:  int(s.Deprecated),
+			Unscored:    int(s.Unscored),
+			MinScore:    s.MinScore,
+			MedianScore: s.MedianScore,
+		}
+		if s.Ecosystems != nil {
+			p.Summary.Ecosystems = map[string]int{}
+			for e, n := range s.Ecosystems {
+				p.Summary.Ecosystems[e] = int(n)
+			}
+		}
+	}
+
+	for _, ps := range pb.GetPackages() {
+		score := PackageScore{
+			PackageInfo: PackageInfo{
+				Package:     ps.Package,
+				Version:     ps.Version,
+				Identifiers: ps.Identifiers,
+				Ecosystem:   ps.Ecosystem,
+			},
+			Score:           ps.Score,
+			ActivityScore:   ps.ActivityScore,
+			ProvenanceScore: ps.ProvenanceScore,
+			Malicious:       ps.Malicious,
+			Deprecated:      ps.Deprecated,
+			Scope:           Scope(ps.Scope),
+			Unscored:        ps.Unscored,
+		}
+		if ps.Retrieved != nil {
+			t := ps.Retrieved.AsTime()
+			score.Retrieved = &t
+		}
+		if ps.Details != nil {
+			score.Details = ps.Details.AsMap()
+		}
+		p.Packages = append(p.Packages, score)
+	}
+	return p
+}
+
+// detailsToStruct converts the score details to a protobuf struct. The
+// details are decoded JSON, they are converted through their encoding to
+// support any value type.
+func detailsToStruct(details map[string]any) (*structpb.Struct, error) {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,121 @@
+package trusty
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty/predicatepb"
+)
+
+// testPredicate returns a predicate with every field set
+func testPredicate() *Predicate {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	retrieved := date.Add(-time.Hour)
+	scores := []PackageScore{
+		{
+			PackageInfo: PackageInfo{
+				Package: "lodash", Version: "4.17.21", Ecosystem: "npm",
+				Identifiers: map[string]string{"purl": "pkg:npm/lodash@4.17.21"},
+			},
+			Score:           7.5,
+			ActivityScore:   6.2,
+			ProvenanceScore: 3,
+			Details:         map[string]any{"description": "popular", "stars": float64(50000), "tags": []any{"utility"}},
+			Scope:           ScopeRuntime,
+			Retrieved:       &retrieved,
+		},
+		{
+			PackageInfo: PackageInfo{
+				Package: "evil", Version: "0.0.1", Ecosystem: "npm",
+				Identifiers: map[string]string{"purl": "pkg:npm/evil@0.0.1"},
+			},
+			Score:      1.2,
+			Malicious:  true,
+			Deprecated: true,
+			Scope:      ScopeDev,
+			Retrieved:  &retrieved,
+		},
+		{
+			PackageInfo: PackageInfo{
+				Package: "fresh", Version: "1.0.0", Ecosystem: "npm",
+				Identifiers: map[string]string{"purl": "pkg:npm/fresh@1.0.0"},
+			},
+			Unscored:  true,
+			Scope:     ScopeRuntime,
+			Retrieved: &retrieved,
+		},
+	}
+	return &Predicate{
+		Metadata: Metadata{
+			Date: &date,
+			PackageInfo: PackageInfo{
+				Package: "app", Version: "1.0.0", Ecosystem: "npm",
+				Identifiers: map[string]string{"purl": "pkg:npm/app@1.0.0"},
+			},
+			Source:     &SourceInfo{Repository: "https://example.com/app.git", Commit: "0123456789abcdef", Tag: "v1.0.0"},
+			Tool:       &ToolInfo{Name: "trusty-attest", Version: "v0.1.0"},
+			Endpoint:   "https://api.trustypkg.dev",
+			Input:      &InputInfo{Type: InputDirectory, Path: "web", Digest: map[string]string{"sha256": helloSHA256}},
+			Ecosystems: []string{"npm"},
+			Options: &ScanOptions{
+				Scope: "all", Transients: true, Recursive: true,
+				Include: []string{"web"}, Exclude: []string{"vendor"}, Revision: "v1.0.0",
+			},
+		},
+		Summary:  Summarize(scores),
+		Packages: scores,
+	}
+}
+
+// jsonValue decodes the JSON encoding of v to compare encodings regardless
+// of key order and number types
+func jsonValue(t *testing.T, v any) any {
+	t.Helper()
+	data, ok := v.([]byte)
+	if !ok {
+		var err error
+		data, err = json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ret any
+	if err := json.Unmarshal(data, &ret); err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func TestPredicateProtoRoundTrip(t *testing.T) {
+	p := testPredicate()
+	pb, err := p.ToProto()
+	if err != nil {
+		t.Fatalf("converting to proto: %v", err)
+	}
+	data, err := protojson.Marshal(pb)
+	if err != nil {
+		t.Fatalf("encoding proto: %v", err)
+	}
+
+	// The protojson encoding omits zero values, it is a valid predicate
+	// that decodes to the same struct
+	parsed, err := ParsePredicate(data)
+	if err != nil {
+		t.Fatalf("parsing protojson encoding: %v", err)
+	}
+	if got, want := jsonValue(t, parsed), jsonValue(t, p); !reflect.DeepEqual(got, want) {
+		t.Errorf("protojson encoding differs:\ngot  %v\nwant %v", got, want)
+	}
+
+	decoded := &predicatepb.Predicate{}
+	if err := protojson.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("decoding proto: %v", err)
+	}
+	if got, want := jsonValue(t, PredicateFromProto(decoded)), jsonValue(t, p); !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip differs:\ngot  %v\nwant %v", got, want)
+	}
+}
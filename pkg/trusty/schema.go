@@ -0,0 +1,62 @@
+package trusty
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// PredicateType is the versioned type URI of the Trusty predicate. It
+// changes when the predicate schema does.
+const PredicateType = "https://trustypkg.dev/attestation/v0.1"
+
+//go:embed predicate.schema.json
+var predicateSchema []byte
+
+// PredicateSchema returns the JSON schema of the predicate
+func PredicateSchema() []byte {
+	return predicateSchema
+}
+
+// ValidatePredicate checks a JSON encoded predicate against the schema
+func ValidatePredicate(data []byte) error {
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewBytesLoader(predicateSchema),
+		gojsonschema.NewBytesLoader(data),
+	)
+	if err != nil {
+		return fmt.Errorf("validating predicate: %w", err)
+	}
+	if result.Valid() {
+		return nil
+	}
+	errs := []error{}
+	for _, e := range result.Errors() {
+		errs = append(errs, errors.New(e.String()))
+	}
+	return fmt.Errorf("predicate does not match the schema: %w", errors.Join(errs...))
+}
+
+// Validate checks the predicate against the schema
+func (p *Predicate) Validate() error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("encoding predicate: %w", err)
+	}
+	return ValidatePredicate(data)
+}
+
+// ParsePredicate validates a JSON encoded predicate and decodes it
+func ParsePredicate(data []byte) (*Predicate, error) {
+	if err := ValidatePredicate(data); err != nil {
+		return nil, err
+	}
+	p := &Predicate{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("decoding predicate: %w", err)
+	}
+	return p, nil
+}
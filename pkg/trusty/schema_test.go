@@ -0,0 +1,115 @@
+package trusty
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+)
+
+func TestValidatePredicate(t *testing.T) {
+	full, err := json.Marshal(testPredicate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name      string
+		predicate string
+		valid     bool
+	}{
+		{"full", string(full), true},
+		{"minimal", `{"metadata": {}}`, true},
+		{"zero-values-omitted", `{"metadata": {}, "packages": [{"package": "lodash"}]}`, true},
+		{"no-metadata", `{"packages": []}`, false},
+		{"package-without-name", `{"metadata": {}, "packages": [{"version": "1.0.0"}]}`, false},
+		{"unknown-scope", `{"metadata": {}, "packages": [{"package": "lodash", "scope": "test"}]}`, false},
+		{"score-as-string", `{"metadata": {}, "packages": [{"package": "lodash", "score": "7.5"}]}`, false},
+		{"bad-date", `{"metadata": {"date": "yesterday"}}`, false},
+		{"negative-count", `{"metadata": {}, "summary": {"total": -1}}`, false},
+		{"unknown-input", `{"metadata": {"input": {"type": "image"}}}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePredicate([]byte(tc.predicate))
+			if tc.valid && err != nil {
+				t.Errorf("expected a valid predicate, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected the predicate to be rejected")
+			}
+		})
+	}
+}
+
+func TestParseStatement(t *testing.T) {
+	subjects := []*intotov1.ResourceDescriptor{
+		{Name: "app.tar.gz", Digest: map[string]string{"sha256": helloSHA256}},
+	}
+	v1, err := Attest(subjects, testPredicate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1Data, err := CanonicalJSON(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v01, err := AttestV01(subjects, testPredicate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v01Data, err := CanonicalJSON(v01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := os.ReadFile("testdata/legacy-statement.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := strings.Replace(string(v1Data), `"scope": "runtime"`, `"scope": "test"`, 1)
+	foreign := strings.Replace(string(v1Data), PredicateType, "https://slsa.dev/provenance/v1", 1)
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		statementType string
+		predicateType string
+		err           string
+	}{
+		{"v1", string(v1Data), intotov1.StatementTypeUri, PredicateType, ""},
+		{"v0.1", string(v01Data), intoto.StatementInTotoV01, PredicateType, ""},
+		{"legacy", string(legacy), intoto.StatementInTotoV01, LegacyPredicateType, ""},
+		{"invalid-predicate", invalid, "", "", "does not match the schema"},
+		{"other-predicate-type", foreign, "", "", "not a Trusty attestation"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := ParseStatement([]byte(tc.data))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsing statement: %v", err)
+			}
+			if st.Type != tc.statementType || st.PredicateType != tc.predicateType {
+				t.Errorf("got statement %q of %q", st.Type, st.PredicateType)
+			}
+			if len(st.Subjects) != 1 || st.Subjects[0].GetDigest()["sha256"] != helloSHA256 {
+				t.Errorf("unexpected subjects %v", st.Subjects)
+			}
+			if len(st.Predicate.Packages) == 0 || st.Predicate.Packages[0].Package != "lodash" {
+				t.Errorf("unexpected packages %v", st.Predicate.Packages)
+			}
+			// Zero scores mark the packages Trusty has no score for only
+			// in legacy attestations
+			for _, ps := range st.Predicate.Packages {
+				if ps.Unscored != (ps.Package == "fresh") {
+					t.Errorf("%s: unscored %v", ps.Package, ps.Unscored)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package trusty
+
+import "github.com/protobom/protobom/pkg/sbom"
+
+// Scope captures when a dependency is required by the project
+type Scope string
+
+const (
+	ScopeRuntime  Scope = "runtime"
+	ScopeOptional Scope = "optional"
+	ScopePeer     Scope = "peer"
+	ScopeDev      Scope = "dev"
+)
+
+// scopeRanks orders the scopes from the most to the least critical. When a
+// package is required in more than one scope, the lowest rank wins.
+var scopeRanks = map[Scope]int{
+	ScopeRuntime:  0,
+	ScopePeer:     1,
+	ScopeOptional: 2,
+	ScopeDev:      3,
+}
+
+// Rank returns the position of the scope in the criticality order. The
+// blank scope is considered runtime.
+func (s Scope) Rank() int {
+	return scopeRanks[s]
+}
+
+// IsDev returns true if the dependency is only required for development
+func (s Scope) IsDev() bool {
+	return s == ScopeDev
+}
+
+// EdgeType returns the protobom edge type used to record a dependency in
+// the scope. Except for runtime dependencies, the edge types are the SPDX
+// *_DEPENDENCY_OF relationships, see DependencyOfEdge.
+func (s Scope) EdgeType() sbom.Edge_Type {
+	switch s {
+	case ScopeDev:
+		return sbom.Edge_devDependency
+	case ScopeOptional:
+		return sbom.Edge_optionalDependency
+	case ScopePeer:
+		return sbom.Edge_providedDependency
+	default:
+		return sbom.Edge_dependsOn
+	}
+}
+
+// edgeScopes maps the protobom edge types that express a dependency to the
+// scope they record.
+var edgeScopes = map[sbom.Edge_Type]Scope{
+	sbom.Edge_contains:           ScopeRuntime,
+	sbom.Edge_dependsOn:          ScopeRuntime,
+	sbom.Edge_dependencyOf:       ScopeRuntime,
+	sbom.Edge_runtimeDependency:  ScopeRuntime,
+	sbom.Edge_staticLink:         ScopeRuntime,
+	sbom.Edge_dynamicLink:        ScopeRuntime,
+	sbom.Edge_optionalDependency: ScopeOptional,
+	sbom.Edge_optionalComponent:  ScopeOptional,
+	sbom.Edge_providedDependency: ScopePeer,
+	sbom.Edge_devDependency:      ScopeDev,
+	sbom.Edge_devTool:            ScopeDev,
+	sbom.Edge_testDependency:     ScopeDev,
+	sbom.Edge_testTool:           ScopeDev,
+	sbom.Edge_buildDependency:    ScopeDev,
+	sbom.Edge_buildTool:          ScopeDev,
+}
+
+// EdgeScope returns the scope recorded by an edge type. The second value is
+// false if the edge type does not express a dependency.
+func EdgeScope(t sbom.Edge_Type) (Scope, bool) {
+	s, ok := edgeScopes[t]
+	return s, ok
+}
+
+// DependencyOfEdge returns true if edges of type t point from the
+// dependency to the dependent, like the SPDX *_DEPENDENCY_OF relationships,
+// instead of from the dependent to the dependency.
+func DependencyOfEdge(t sbom.Edge_Type) bool {
+	switch t {
+	case sbom.Edge_contains, sbom.Edge_dependsOn, sbom.Edge_staticLink, sbom.Edge_dynamicLink:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,95 @@
+package trusty
+
+import (
+	"encoding/json"
+	"fmt"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// LegacyPredicateType is the unversioned predicate type of the attestations
+// generated before the predicate schema was published. It is still accepted
+// when reading statements.
+const LegacyPredicateType = "http://trustypkg.dev"
+
+// Statement is a Trusty attestation read from an in-toto statement of any
+// of the supported versions. Subjects of v0.1 statements are converted to
+// resource descriptors.
+type Statement struct {
+	// Type is the in-toto statement type URI
+	Type          string
+	PredicateType string
+	Subjects      []*intotov1.ResourceDescriptor
+	Predicate     *Predicate
+}
+
+// IsStatement returns true if data is a JSON encoded in-toto statement,
+// of any version
+func IsStatement(data []byte) bool {
+	header := struct {
+		Type string `json:"_type"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return false
+	}
+	return header.Type == intotov1.StatementTypeUri || header.Type == intoto.StatementInTotoV01
+}
+
+// ParseStatement decodes an in-toto v1 or v0.1 statement carrying a Trusty
+// predicate. The predicate is validated against the schema.
+func ParseStatement(data []byte) (*Statement, error) {
+	header := struct {
+		Type          string          `json:"_type"`
+		PredicateType string          `json:"predicateType"`
+		Predicate     json.RawMessage `json:"predicate"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("decoding statement: %w", err)
+	}
+	if header.PredicateType != PredicateType && header.PredicateType != LegacyPredicateType {
+		return nil, fmt.Errorf("statement is not a Trusty attestation, predicate type is %q", header.PredicateType)
+	}
+
+	st := &Statement{Type: header.Type, PredicateType: header.PredicateType}
+	switch header.Type {
+	case intotov1.StatementTypeUri:
+		v1 := &intotov1.Statement{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, v1); err != nil {
+			return nil, fmt.Errorf("decoding v1 statement: %w", err)
+		}
+		for _, s := range v1.GetSubject() {
+			if err := s.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid subject %q: %w", s.GetName(), err)
+			}
+		}
+		st.Subjects = v1.GetSubject()
+	case intoto.StatementInTotoV01:
+		v01 := &intoto.StatementHeader{}
+		if err := json.Unmarshal(data, v01); err != nil {
+			return nil, fmt.Errorf("decoding v0.1 statement: %w", err)
+		}
+		for _, s := range v01.Subject {
+			st.Subjects = append(st.Subjects, &intotov1.ResourceDescriptor{
+				Name: s.Name, Digest: s.Digest,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported statement type %q", header.Type)
+	}
+
+	pred, err := ParsePredicate(header.Predicate)
+	if err != nil {
+		return nil, err
+	}
+	// Legacy attestations recorded the packages Trusty has no score for
+	// with a zero score, without marking them
+	if header.PredicateType == LegacyPredicateType {
+		for i := range pred.Packages {
+			pred.Packages[i].Unscored = pred.Packages[i].Score == 0
+		}
+	}
+	st.Predicate = pred
+	return st, nil
+}
@@ -0,0 +1,92 @@
+package trusty
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+)
+
+// subjectAlgorithms are the digest algorithms accepted in subjects with
+// the length of their hex encoded values
+var subjectAlgorithms = map[string]int{
+	"sha256": sha256.Size * 2,
+	"sha384": sha512.Size384 * 2,
+	"sha512": sha512.Size * 2,
+}
+
+// FileSubject returns a subject for the file at path, named after the path
+// and with its sha256 and sha512 digests.
+func FileSubject(path string) (*intotov1.ResourceDescriptor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening subject file: %w", err)
+	}
+	defer f.Close()
+
+	h256, h512 := sha256.New(), sha512.New()
+	if _, err := io.Copy(io.MultiWriter(h256, h512), f); err != nil {
+		return nil, fmt.Errorf("hashing %s: %w", path, err)
+	}
+
+	return &intotov1.ResourceDescriptor{
+		Name: path,
+		Digest: map[string]string{
+			"sha256": hex.EncodeToString(h256.Sum(nil)),
+			"sha512": hex.EncodeToString(h512.Sum(nil)),
+		},
+	}, nil
+}
+
+// ParseSubjectDigest parses a subject expressed as name=algorithm:digest
+// (eg myapp=sha256:2c26b46b...)
+func ParseSubjectDigest(s string) (*intotov1.ResourceDescriptor, error) {
+	name, digest, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("subject %q is not in name=algorithm:digest format", s)
+	}
+	algo, value, ok := strings.Cut(digest, ":")
+	if !ok {
+		return nil, fmt.Errorf("digest %q is not in algorithm:digest format", digest)
+	}
+
+	algo = strings.ToLower(algo)
+	value = strings.ToLower(value)
+	size, ok := subjectAlgorithms[algo]
+	if !ok {
+		return nil, fmt.Errorf("unsupported digest algorithm %q", algo)
+	}
+	if _, err := hex.DecodeString(value); err != nil || len(value) != size {
+		return nil, fmt.Errorf("invalid %s digest %q", algo, value)
+	}
+	return &intotov1.ResourceDescriptor{Name: name, Digest: map[string]string{algo: value}}, nil
+}
+
+// MatchSubjects checks that every artifact matches one of the subjects. An
+// artifact matches when both share a digest algorithm and its value.
+func MatchSubjects(subjects, artifacts []*intotov1.ResourceDescriptor) error {
+	for _, a := range artifacts {
+		if MatchingSubject(subjects, a) == nil {
+			return fmt.Errorf("%s does not match any of the %d subjects", a.GetName(), len(subjects))
+		}
+	}
+	return nil
+}
+
+// MatchingSubject returns the first subject matching the artifact, nil if
+// none does
+func MatchingSubject(subjects []*intotov1.ResourceDescriptor, artifact *intotov1.ResourceDescriptor) *intotov1.ResourceDescriptor {
+	for _, s := range subjects {
+		for algo, value := range artifact.GetDigest() {
+			if v, ok := s.GetDigest()[algo]; ok && strings.EqualFold(v, value) {
+				return s
+			}
+		}
+	}
+	return nil
+}
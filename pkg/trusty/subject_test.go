@@ -0,0 +1,72 @@
+package trusty
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+)
+
+// helloSHA256 is the sha256 digest of "hello"
+const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestFileSubject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := FileSubject(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.GetName() != path || s.GetDigest()["sha256"] != helloSHA256 || len(s.GetDigest()["sha512"]) != 128 {
+		t.Errorf("unexpected subject %v", s)
+	}
+}
+
+func TestParseSubjectDigest(t *testing.T) {
+	s, err := ParseSubjectDigest("myapp=SHA256:" + helloSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.GetName() != "myapp" || s.GetDigest()["sha256"] != helloSHA256 {
+		t.Errorf("unexpected subject %v", s)
+	}
+
+	for _, bad := range []string{
+		"sha256:" + helloSHA256,
+		"myapp=" + helloSHA256,
+		"myapp=md5:" + helloSHA256[:32],
+		"myapp=sha256:" + helloSHA256[:10],
+		"myapp=sha256:" + helloSHA256[:62] + "zz",
+	} {
+		if _, err := ParseSubjectDigest(bad); err == nil {
+			t.Errorf("expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestMatchSubjects(t *testing.T) {
+	subjects := []*intotov1.ResourceDescriptor{
+		{Name: "myapp", Digest: map[string]string{"sha256": helloSHA256, "gitCommit": "abc"}},
+	}
+	for _, tc := range []struct {
+		name      string
+		artifacts []*intotov1.ResourceDescriptor
+		match     bool
+	}{
+		{"no artifacts", nil, true},
+		{"same digest", []*intotov1.ResourceDescriptor{{Name: "x", Digest: map[string]string{"sha256": helloSHA256}}}, true},
+		{"case insensitive", []*intotov1.ResourceDescriptor{{Name: "x", Digest: map[string]string{"gitCommit": "ABC"}}}, true},
+		{"other digest", []*intotov1.ResourceDescriptor{{Name: "x", Digest: map[string]string{"sha256": "00"}}}, false},
+		{"other algorithm", []*intotov1.ResourceDescriptor{{Name: "x", Digest: map[string]string{"sha512": helloSHA256}}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := MatchSubjects(subjects, tc.artifacts)
+			if (err == nil) != tc.match {
+				t.Errorf("MatchSubjects() error = %v, want match %v", err, tc.match)
+			}
+		})
+	}
+}
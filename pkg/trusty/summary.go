@@ -0,0 +1,82 @@
+package trusty
+
+import "slices"
+
+// HighScoreThreshold is the score at or above which a package is in the
+// high score band
+const HighScoreThreshold = 8.0
+
+// Summary aggregates the package scores of a predicate so consumers don't
+// have to walk the packages to answer common questions
+type Summary struct {
+	// Total is the number of packages in the predicate
+	Total int `json:"total"`
+	// Ecosystems counts the packages of each ecosystem
+	Ecosystems map[string]int `json:"ecosystems,omitempty"`
+	// ScoreBands counts the scored packages in each score band
+	ScoreBands ScoreBands `json:"scoreBands"`
+	Malicious  int        `json:"malicious"`
+	Deprecated int        `json:"deprecated"`
+	// Unscored counts the packages Trusty has no score for
+	Unscored int `json:"unscored"`
+	// MinScore and MedianScore are computed over the scored packages,
+	// they are unset when no package is scored
+	MinScore    *float64 `json:"minScore,omitempty"`
+	MedianScore *float64 `json:"medianScore,omitempty"`
+}
+
+// ScoreBands counts packages by score: low packages score at or below
+// RiskyScoreThreshold, high ones at or above HighScoreThreshold and medium
+// ones in between.
+type ScoreBands struct {
+	Low    int `json:"low"`
+	Medium int `json:"medium"`
+	High   int `json:"high"`
+}
+
+// Summarize computes the summary of a list of package scores. Unscored
+// packages are counted apart and left out of the bands, minimum and median.
+func Summarize(scores []PackageScore) *Summary {
+	s := &Summary{Total: len(scores)}
+	scored := []float64{}
+	for i := range scores {
+		ps := &scores[i]
+		if ps.Ecosystem != "" {
+			if s.Ecosystems == nil {
+				s.Ecosystems = map[string]int{}
+			}
+			s.Ecosystems[ps.Ecosystem]++
+		}
+		if ps.Malicious {
+			s.Malicious++
+		}
+		if ps.Deprecated {
+			s.Deprecated++
+		}
+
+		switch {
+		case ps.Unscored:
+			s.Unscored++
+			continue
+		case ps.IsRisky():
+			s.ScoreBands.Low++
+		case ps.Score >= HighScoreThreshold:
+			s.ScoreBands.High++
+		default:
+			s.ScoreBands.Medium++
+		}
+		scored = append(scored, ps.Score)
+	}
+
+	if len(scored) == 0 {
+		return s
+	}
+	slices.Sort(scored)
+	median := scored[len(scored)/2]
+	if len(scored)%2 == 0 {
+		median = (scored[len(scored)/2-1] + median) / 2
+	}
+	s.MinScore = &scored[0]
+	s.MedianScore = &median
+	return s
+}
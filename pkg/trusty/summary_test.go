@@ -0,0 +1,56 @@
+package trusty
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	score := func(ecosystem string, s float64) PackageScore {
+		return PackageScore{PackageInfo: PackageInfo{Ecosystem: ecosystem}, Score: s}
+	}
+	unscored := score("pypi", 0)
+	unscored.Unscored = true
+	malicious := score("npm", 9)
+	malicious.Malicious = true
+
+	min, median := 0.0, 6.5
+	for _, tc := range []struct {
+		name     string
+		scores   []PackageScore
+		expected *Summary
+	}{
+		{
+			// A zero score is scored, only the unscored mark leaves a
+			// package out of the bands
+			name:   "mixed",
+			scores: []PackageScore{score("npm", 0), score("npm", 6), score("go", 7), malicious, unscored},
+			expected: &Summary{
+				Total:      5,
+				Ecosystems: map[string]int{"npm": 3, "go": 1, "pypi": 1},
+				ScoreBands: ScoreBands{Low: 1, Medium: 2, High: 1},
+				Malicious:  1,
+				Unscored:   1,
+				MinScore:   &min,
+				// The mean of the middle scores
+				MedianScore: &median,
+			},
+		},
+		{
+			name:     "unscored",
+			scores:   []PackageScore{unscored},
+			expected: &Summary{Total: 1, Ecosystems: map[string]int{"pypi": 1}, Unscored: 1},
+		},
+		{
+			name:     "empty",
+			scores:   nil,
+			expected: &Summary{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if s := Summarize(tc.scores); !reflect.DeepEqual(s, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, s)
+			}
+		})
+	}
+}
@@ -16,35 +16,135 @@
 package trusty
 
 import (
+	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 
+	intotov1 "github.com/in-toto/attestation/go/v1"
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"sigs.k8s.io/release-utils/version"
 )
 
+// ToolName is the name the tool records in the predicates it generates
+const ToolName = "trusty-attest"
+
 type PredicateOpts struct {
-	Package PackageInfo
+	Package    PackageInfo
+	Source     *SourceInfo
+	Endpoint   string
+	Input      *InputInfo
+	Ecosystems []string
+	Options    *ScanOptions
+	// Date is the time recorded in the predicate, it defaults to the
+	// current time. When set, the retrieval times of the packages are
+	// clamped to it so the predicate can be reproduced.
+	Date *time.Time
 }
 
+// BuildPredicate returns the predicate of the scored packages. The
+// packages are sorted canonically so the same input always produces the
+// same predicate.
 func BuildPredicate(opts PredicateOpts, scores []PackageScore) (*Predicate, error) {
 	t := time.Now()
+	if opts.Date != nil {
+		t = *opts.Date
+	}
+	scores = slices.Clone(scores)
+	if scores == nil {
+		scores = []PackageScore{}
+	}
+	SortPackages(scores)
+	if opts.Date != nil {
+		for i := range scores {
+			if r := scores[i].Retrieved; r != nil && r.After(t) {
+				scores[i].Retrieved = &t
+			}
+		}
+	}
 	pred := &Predicate{
 		Metadata: Metadata{
 			Date:        &t,
 			PackageInfo: opts.Package,
+			Source:      opts.Source,
+			Tool: &ToolInfo{
+				Name:    ToolName,
+				Version: version.GetVersionInfo().GitVersion,
+			},
+			Endpoint:   opts.Endpoint,
+			Input:      opts.Input,
+			Ecosystems: opts.Ecosystems,
+			Options:    opts.Options,
 		},
+		Summary:  Summarize(scores),
 		Packages: scores,
 	}
 
+	if err := pred.Validate(); err != nil {
+		return nil, err
+	}
 	return pred, nil
 }
 
-func Attest(subjects []intoto.Subject, p *Predicate) (intoto.Statement, error) {
+// Attest wraps the predicate in an in-toto v1 statement about subjects
+func Attest(subjects []*intotov1.ResourceDescriptor, p *Predicate) (*intotov1.Statement, error) {
+	return newStatement(subjects, PredicateType, p)
+}
+
+// newStatement returns an in-toto v1 statement of the predicate type
+// wrapping the JSON encoding of the predicate
+func newStatement(subjects []*intotov1.ResourceDescriptor, predicateType string, p any) (*intotov1.Statement, error) {
+	for _, s := range subjects {
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid subject %q: %w", s.GetName(), err)
+		}
+	}
+
+	pred, err := predicateStruct(p)
+	if err != nil {
+		return nil, err
+	}
+	return &intotov1.Statement{
+		Type:          intotov1.StatementTypeUri,
+		Subject:       subjects,
+		PredicateType: predicateType,
+		Predicate:     pred,
+	}, nil
+}
+
+// AttestV01 wraps the predicate in a legacy in-toto v0.1 statement. The
+// subjects are reduced to their name (or URI) and digests.
+func AttestV01(subjects []*intotov1.ResourceDescriptor, p *Predicate) (intoto.Statement, error) {
+	v01Subjects := []intoto.Subject{}
+	for _, s := range subjects {
+		name := s.GetName()
+		if name == "" {
+			name = s.GetUri()
+		}
+		v01Subjects = append(v01Subjects, intoto.Subject{Name: name, Digest: s.GetDigest()})
+	}
 	return intoto.Statement{
 		StatementHeader: intoto.StatementHeader{
 			Type:          intoto.StatementInTotoV01,
-			PredicateType: "http://trustypkg.dev",
-			Subject:       []intoto.Subject{},
+			PredicateType: PredicateType,
+			Subject:       v01Subjects,
 		},
 		Predicate: p,
 	}, nil
 }
+
+// predicateStruct converts a predicate to the protobuf struct embedded in
+// v1 statements
+func predicateStruct(p any) (*structpb.Struct, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("encoding predicate: %w", err)
+	}
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("converting predicate: %w", err)
+	}
+	return s, nil
+}
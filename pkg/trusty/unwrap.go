@@ -0,0 +1,70 @@
+package trusty
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Wrappers of the predicate detected by Unwrap
+const (
+	WrapperBundle    = "bundle"
+	WrapperEnvelope  = "envelope"
+	WrapperStatement = "statement"
+	WrapperPredicate = "predicate"
+)
+
+// envelope is the JSON encoding of a DSSE envelope, bare or in a bundle
+type envelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+// Unwrap decodes the predicate from any of the formats attestations are
+// written in: a sigstore bundle, a DSSE envelope, an in-toto statement or
+// a bare predicate. Signatures are NOT verified. It returns the statement
+// (without subjects for bare predicates) and the wrapper detected.
+func Unwrap(data []byte) (*Statement, string, error) {
+	header := struct {
+		MediaType    string    `json:"mediaType"`
+		DSSEEnvelope *envelope `json:"dsseEnvelope"`
+		envelope
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, "", fmt.Errorf("decoding attestation: %w", err)
+	}
+
+	switch {
+	case header.MediaType != "":
+		if header.DSSEEnvelope == nil {
+			return nil, "", errors.New("bundle does not contain a DSSE envelope")
+		}
+		st, err := envelopeStatement(header.DSSEEnvelope)
+		return st, WrapperBundle, err
+	case header.PayloadType != "":
+		st, err := envelopeStatement(&header.envelope)
+		return st, WrapperEnvelope, err
+	case IsStatement(data):
+		st, err := ParseStatement(data)
+		return st, WrapperStatement, err
+	default:
+		pred, err := ParsePredicate(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return &Statement{PredicateType: PredicateType, Predicate: pred}, WrapperPredicate, nil
+	}
+}
+
+// envelopeStatement decodes the statement in the payload of an envelope
+func envelopeStatement(env *envelope) (*Statement, error) {
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding envelope payload: %w", err)
+	}
+	if !IsStatement(payload) {
+		return nil, fmt.Errorf("envelope payload of type %q is not an in-toto statement", env.PayloadType)
+	}
+	return ParseStatement(payload)
+}
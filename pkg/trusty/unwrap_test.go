@@ -0,0 +1,80 @@
+package trusty
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+)
+
+func TestUnwrap(t *testing.T) {
+	subjects := []*intotov1.ResourceDescriptor{
+		{Name: "app.tar.gz", Digest: map[string]string{"sha256": helloSHA256}},
+	}
+	st, err := Attest(subjects, testPredicate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement, err := CanonicalJSON(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	predicate, err := json.Marshal(testPredicate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// envelope returns a DSSE envelope carrying payload, signatures are
+	// not checked
+	envelope := func(payload []byte) string {
+		return `{"payloadType": "application/vnd.in-toto+json", "payload": "` +
+			base64.StdEncoding.EncodeToString(payload) + `", "signatures": [{"sig": "c2ln"}]}`
+	}
+	bundle := func(env string) string {
+		return `{"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json", "dsseEnvelope": ` + env + `}`
+	}
+
+	for _, tc := range []struct {
+		name     string
+		data     string
+		wrapper  string
+		subjects bool
+	}{
+		{name: "predicate", data: string(predicate), wrapper: WrapperPredicate},
+		{name: "statement", data: string(statement), wrapper: WrapperStatement, subjects: true},
+		{name: "envelope", data: envelope(statement), wrapper: WrapperEnvelope, subjects: true},
+		{name: "bundle", data: bundle(envelope(statement)), wrapper: WrapperBundle, subjects: true},
+		{name: "not json", data: "predicate"},
+		{name: "invalid predicate", data: `{"packages": "none"}`},
+		{name: "bundle without envelope", data: `{"mediaType": "application/vnd.dev.sigstore.bundle.v0.3+json"}`},
+		{name: "invalid payload", data: `{"payloadType": "application/vnd.in-toto+json", "payload": "!"}`},
+		{name: "predicate payload", data: envelope(predicate)},
+		{name: "bundled predicate payload", data: bundle(envelope(predicate))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st, wrapper, err := Unwrap([]byte(tc.data))
+			if tc.wrapper == "" {
+				if err == nil {
+					t.Fatalf("expected an error, got a %s", wrapper)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unwrapping: %v", err)
+			}
+			if wrapper != tc.wrapper {
+				t.Errorf("wrapper = %q, want %q", wrapper, tc.wrapper)
+			}
+			if st.PredicateType != PredicateType {
+				t.Errorf("predicate type = %q", st.PredicateType)
+			}
+			if tc.subjects != (len(st.Subjects) == 1) {
+				t.Errorf("unexpected subjects %v", st.Subjects)
+			}
+			if !reflect.DeepEqual(jsonValue(t, st.Predicate), jsonValue(t, testPredicate())) {
+				t.Errorf("predicate differs from the wrapped one:\n%+v", st.Predicate)
+			}
+		})
+	}
+}
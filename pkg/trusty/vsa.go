@@ -0,0 +1,106 @@
+package trusty
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	"sigs.k8s.io/release-utils/version"
+)
+
+// VSAPredicateType is the predicate type of SLSA verification summaries
+const VSAPredicateType = "https://slsa.dev/verification_summary/v1"
+
+// VerifierID identifies trusty as the verifier in the summaries it writes
+const VerifierID = "https://github.com/stacklok/trusty-attest"
+
+// Verification results recorded in summaries
+const (
+	VerificationPassed = "PASSED"
+	VerificationFailed = "FAILED"
+)
+
+// VerifiedLevelPolicy is the verified level recorded when the Trusty
+// policy passes. SLSA reserves the SLSA_ prefix and the FAILED value,
+// custom levels are allowed otherwise.
+const VerifiedLevelPolicy = "TRUSTY_POLICY"
+
+// VSA is a SLSA verification summary predicate stating which policy was
+// applied to an artifact, to which attestations and with what result
+type VSA struct {
+	Verifier           VSAVerifier   `json:"verifier"`
+	TimeVerified       *time.Time    `json:"timeVerified,omitempty"`
+	ResourceURI        string        `json:"resourceUri"`
+	Policy             ResourceRef   `json:"policy"`
+	InputAttestations  []ResourceRef `json:"inputAttestations,omitempty"`
+	VerificationResult string        `json:"verificationResult"`
+	VerifiedLevels     []string      `json:"verifiedLevels"`
+}
+
+// VSAVerifier identifies the program that did the verification
+type VSAVerifier struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// ResourceRef points to a resource by URI and digest
+type ResourceRef struct {
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// NewVSA returns the verification summary of a policy evaluation about
+// subject, its resourceUri is the subject URI or else its name. The
+// verifier is set to this tool and the verified levels to match the result.
+func NewVSA(subject *intotov1.ResourceDescriptor, policy ResourceRef, inputs []ResourceRef, passed bool, verified time.Time) *VSA {
+	resourceURI := subject.GetUri()
+	if resourceURI == "" {
+		resourceURI = subject.GetName()
+	}
+	vsa := &VSA{
+		Verifier: VSAVerifier{
+			ID:      VerifierID,
+			Version: map[string]string{ToolName: version.GetVersionInfo().GitVersion},
+		},
+		TimeVerified:       &verified,
+		ResourceURI:        resourceURI,
+		Policy:             policy,
+		InputAttestations:  inputs,
+		VerificationResult: VerificationPassed,
+		VerifiedLevels:     []string{VerifiedLevelPolicy},
+	}
+	if !passed {
+		vsa.VerificationResult = VerificationFailed
+		vsa.VerifiedLevels = []string{VerificationFailed}
+	}
+	return vsa
+}
+
+// VSASubject returns the attestation subject a verification summary is
+// about. A summary records a single resourceUri, so attestations about
+// several subjects need the artifact to summarize: it is selected with the
+// one artifact matching it.
+func VSASubject(subjects, artifacts []*intotov1.ResourceDescriptor) (*intotov1.ResourceDescriptor, error) {
+	switch {
+	case len(artifacts) > 1:
+		return nil, fmt.Errorf("a verification summary is about a single artifact, got %d", len(artifacts))
+	case len(artifacts) == 1:
+		s := MatchingSubject(subjects, artifacts[0])
+		if s == nil {
+			return nil, fmt.Errorf("%s does not match any of the %d subjects", artifacts[0].GetName(), len(subjects))
+		}
+		return s, nil
+	case len(subjects) == 0:
+		return nil, errors.New("the attestation has no subjects to write a verification summary about")
+	case len(subjects) > 1:
+		return nil, fmt.Errorf("the attestation has %d subjects, the artifact to summarize must be passed", len(subjects))
+	}
+	return subjects[0], nil
+}
+
+// AttestVSA wraps a verification summary in an in-toto v1 statement about
+// the verified subject
+func AttestVSA(subject *intotov1.ResourceDescriptor, vsa *VSA) (*intotov1.Statement, error) {
+	return newStatement([]*intotov1.ResourceDescriptor{subject}, VSAPredicateType, vsa)
+}
@@ -0,0 +1,124 @@
+package trusty
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+)
+
+func TestNewVSA(t *testing.T) {
+	verified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := ResourceRef{URI: "policy.yaml", Digest: map[string]string{"sha256": helloSHA256}}
+	inputs := []ResourceRef{{URI: "bundle.json", Digest: map[string]string{"sha256": helloSHA256}}}
+	for _, tc := range []struct {
+		name     string
+		subject  *intotov1.ResourceDescriptor
+		passed   bool
+		resource string
+		result   string
+		levels   []string
+	}{
+		{
+			name:     "passed",
+			subject:  &intotov1.ResourceDescriptor{Name: "myapp"},
+			passed:   true,
+			resource: "myapp",
+			result:   VerificationPassed,
+			levels:   []string{VerifiedLevelPolicy},
+		},
+		{
+			// The URI is preferred to the name
+			name:     "failed",
+			subject:  &intotov1.ResourceDescriptor{Name: "myapp", Uri: "https://example.com/myapp"},
+			resource: "https://example.com/myapp",
+			result:   VerificationFailed,
+			levels:   []string{VerificationFailed},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vsa := NewVSA(tc.subject, policy, inputs, tc.passed, verified)
+			if vsa.ResourceURI != tc.resource {
+				t.Errorf("resourceUri = %q, want %q", vsa.ResourceURI, tc.resource)
+			}
+			if vsa.VerificationResult != tc.result || !slices.Equal(vsa.VerifiedLevels, tc.levels) {
+				t.Errorf("result %s %v, want %s %v", vsa.VerificationResult, vsa.VerifiedLevels, tc.result, tc.levels)
+			}
+			if vsa.Verifier.ID != VerifierID || vsa.TimeVerified == nil || !vsa.TimeVerified.Equal(verified) {
+				t.Errorf("unexpected verifier %v or time %v", vsa.Verifier, vsa.TimeVerified)
+			}
+			if vsa.Policy.URI != policy.URI || len(vsa.InputAttestations) != 1 {
+				t.Errorf("unexpected policy %v or inputs %v", vsa.Policy, vsa.InputAttestations)
+			}
+		})
+	}
+}
+
+func TestVSASubject(t *testing.T) {
+	a := &intotov1.ResourceDescriptor{Name: "a", Digest: map[string]string{"sha256": helloSHA256}}
+	b := &intotov1.ResourceDescriptor{Name: "b", Digest: map[string]string{"gitCommit": "abc"}}
+	artifact := func(digest map[string]string) *intotov1.ResourceDescriptor {
+		return &intotov1.ResourceDescriptor{Name: "dist/app", Digest: digest}
+	}
+	for _, tc := range []struct {
+		name      string
+		subjects  []*intotov1.ResourceDescriptor
+		artifacts []*intotov1.ResourceDescriptor
+		expected  *intotov1.ResourceDescriptor
+	}{
+		{"single subject", []*intotov1.ResourceDescriptor{a}, nil, a},
+		{"no subjects", nil, nil, nil},
+		{"several subjects", []*intotov1.ResourceDescriptor{a, b}, nil, nil},
+		// The subject is returned, not the artifact that matched it
+		{"selected", []*intotov1.ResourceDescriptor{a, b}, []*intotov1.ResourceDescriptor{artifact(map[string]string{"gitCommit": "ABC"})}, b},
+		{"no match", []*intotov1.ResourceDescriptor{a, b}, []*intotov1.ResourceDescriptor{artifact(map[string]string{"sha256": "00"})}, nil},
+		{"several artifacts", []*intotov1.ResourceDescriptor{a, b}, []*intotov1.ResourceDescriptor{a, b}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := VSASubject(tc.subjects, tc.artifacts)
+			if tc.expected == nil {
+				if err == nil {
+					t.Errorf("expected an error, got subject %v", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("choosing subject: %v", err)
+			}
+			if s != tc.expected {
+				t.Errorf("subject = %v, want %v", s, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAttestVSA(t *testing.T) {
+	subject := &intotov1.ResourceDescriptor{Name: "myapp", Digest: map[string]string{"sha256": helloSHA256}}
+	vsa := NewVSA(subject, ResourceRef{URI: "policy.yaml"}, nil, true, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	st, err := AttestVSA(subject, vsa)
+	if err != nil {
+		t.Fatalf("attesting: %v", err)
+	}
+	if st.GetType() != intotov1.StatementTypeUri || st.GetPredicateType() != VSAPredicateType {
+		t.Errorf("unexpected statement type %q or predicate type %q", st.GetType(), st.GetPredicateType())
+	}
+	if len(st.GetSubject()) != 1 || st.GetSubject()[0] != subject {
+		t.Errorf("subjects = %v, want only %v", st.GetSubject(), subject)
+	}
+	fields := st.GetPredicate().GetFields()
+	if got := fields["resourceUri"].GetStringValue(); got != "myapp" {
+		t.Errorf("predicate resourceUri = %q", got)
+	}
+	if got := fields["verificationResult"].GetStringValue(); got != VerificationPassed {
+		t.Errorf("predicate verificationResult = %q", got)
+	}
+	if got := fields["timeVerified"].GetStringValue(); got != "2024-01-01T00:00:00Z" {
+		t.Errorf("predicate timeVerified = %q", got)
+	}
+
+	if _, err := AttestVSA(&intotov1.ResourceDescriptor{Name: "myapp", Digest: map[string]string{"sha256": "zz"}}, vsa); err == nil {
+		t.Error("expected an error attesting about a subject with an invalid digest")
+	}
+}
@@ -0,0 +1,80 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/stacklok/trusty-attest/pkg/sign"
+)
+
+// loadBundle reads a sigstore bundle from a JSON file. When legacy is set,
+// the bundle is expected in the shape written by older versions of trusty
+// attest --bundle (PEM certificates, hex encoded log hashes and base64
+// encoded signatures) and converted before it is checked.
+func loadBundle(path string, legacy bool) (*bundle.ProtobufBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	return parseBundle(data, legacy)
+}
+
+// parseBundle decodes a JSON encoded bundle, see loadBundle
+func parseBundle(data []byte, legacy bool) (*bundle.ProtobufBundle, error) {
+	pb := &protobundle.Bundle{}
+	if err := protojson.Unmarshal(data, pb); err != nil {
+		return nil, fmt.Errorf("decoding bundle: %w", err)
+	}
+	if legacy {
+		if err := sign.DecodeLegacyBundle(pb); err != nil {
+			return nil, fmt.Errorf("decoding legacy bundle: %w", err)
+		}
+	}
+	if err := checkBundleEncoding(pb); err != nil {
+		if !legacy {
+			return nil, fmt.Errorf("%w (bundles written by older versions need --legacy-bundle)", err)
+		}
+		return nil, err
+	}
+	return bundle.NewProtobufBundle(pb)
+}
+
+// checkBundleEncoding rejects bundles whose verification material is not
+// encoded as the bundle spec expects: DER certificates and raw sha256 log
+// ids and inclusion proof hashes.
+func checkBundleEncoding(pb *protobundle.Bundle) error {
+	vm := pb.GetVerificationMaterial()
+	certs := vm.GetX509CertificateChain().GetCertificates()
+	if c := vm.GetCertificate(); c != nil {
+		certs = append(certs, c)
+	}
+	for i, c := range certs {
+		if _, err := x509.ParseCertificate(c.RawBytes); err != nil {
+			return fmt.Errorf("certificate %d is not DER encoded: %w", i, err)
+		}
+	}
+	for _, e := range vm.GetTlogEntries() {
+		if e.LogId != nil && len(e.LogId.KeyId) != sha256.Size {
+			return fmt.Errorf("log id of entry %d is not a sha256 hash", e.LogIndex)
+		}
+		p := e.GetInclusionProof()
+		if p == nil {
+			continue
+		}
+		if len(p.RootHash) != sha256.Size {
+			return fmt.Errorf("inclusion proof root hash of entry %d is not a sha256 hash", e.LogIndex)
+		}
+		for _, h := range p.Hashes {
+			if len(h) != sha256.Size {
+				return fmt.Errorf("inclusion proof of entry %d has a hash that is not sha256", e.LogIndex)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"os"
+	"strings"
+	"testing"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestParseBundle(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		fixture string
+		legacy  bool
+		err     string
+	}{
+		{"spec", "testdata/bundle.json", false, ""},
+		{"legacy", "testdata/legacy-bundle.json", true, ""},
+		{"legacy-without-flag", "testdata/legacy-bundle.json", false, "--legacy-bundle"},
+		{"spec-as-legacy", "testdata/bundle.json", true, "decoding legacy bundle"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(tc.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := parseBundle(data, tc.legacy)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsing bundle: %v", err)
+			}
+
+			vm := b.GetVerificationMaterial()
+			certs := vm.GetX509CertificateChain().GetCertificates()
+			if len(certs) != 2 {
+				t.Fatalf("expected 2 certificates, got %d", len(certs))
+			}
+			for _, c := range certs {
+				if _, err := x509.ParseCertificate(c.RawBytes); err != nil {
+					t.Errorf("certificate is not DER: %v", err)
+				}
+			}
+			entry := vm.GetTlogEntries()[0]
+			if len(entry.LogId.KeyId) != sha256.Size || len(entry.InclusionProof.RootHash) != sha256.Size {
+				t.Errorf("log hashes were not decoded")
+			}
+			sig := b.GetDsseEnvelope().GetSignatures()[0].Sig
+			if len(sig) != 70 || sig[0] != 0x30 {
+				t.Errorf("signature was not decoded: %q", sig)
+			}
+		})
+	}
+}
+
+// The keyless signer writes the certificate chain in a single PEM entry
+func TestParseLegacyBundleChain(t *testing.T) {
+	data, err := os.ReadFile("testdata/legacy-bundle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb := &protobundle.Bundle{}
+	if err := protojson.Unmarshal(data, pb); err != nil {
+		t.Fatal(err)
+	}
+	chain := pb.GetVerificationMaterial().GetX509CertificateChain()
+	chain.Certificates = []*protocommon.X509Certificate{
+		{RawBytes: bytes.Join([][]byte{chain.Certificates[0].RawBytes, chain.Certificates[1].RawBytes}, []byte("\n"))},
+	}
+	data, err = protojson.Marshal(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := parseBundle(data, true)
+	if err != nil {
+		t.Fatalf("parsing bundle: %v", err)
+	}
+	if n := len(b.GetVerificationMaterial().GetX509CertificateChain().GetCertificates()); n != 2 {
+		t.Errorf("expected the chain to be split in 2 certificates, got %d", n)
+	}
+}
@@ -0,0 +1,88 @@
+package verify
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
+)
+
+// verifyWithKey verifies a DSSE envelope, bare or in a bundle, against the
+// PEM public key in the options
+func verifyWithKey(path string, opts *Options) (*Result, error) {
+	env, err := loadEnvelope(path, opts.LegacyBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(opts.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	pub, err := cryptoutils.UnmarshalPEMToPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding public key: %w", err)
+	}
+	v, err := signature.LoadVerifier(pub, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("loading verifier: %w", err)
+	}
+
+	ev, err := dsse.NewEnvelopeVerifier(&sigdsse.VerifierAdapter{SignatureVerifier: v, Pub: pub})
+	if err != nil {
+		return nil, fmt.Errorf("creating envelope verifier: %w", err)
+	}
+	accepted, err := ev.Verify(context.Background(), env)
+	if err != nil {
+		return nil, fmt.Errorf("verifying envelope signature: %w", err)
+	}
+
+	st, err := envelopeStatement(env)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Statement: st, KeyID: accepted[0].KeyID}, nil
+}
+
+// loadEnvelope reads a DSSE envelope from a JSON file, either bare or
+// wrapped in a sigstore bundle (see loadBundle for legacy)
+func loadEnvelope(path string, legacy bool) (*dsse.Envelope, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading attestation: %w", err)
+	}
+	header := struct {
+		MediaType string `json:"mediaType"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("decoding attestation: %w", err)
+	}
+
+	if header.MediaType != "" {
+		b, err := parseBundle(data, legacy)
+		if err != nil {
+			return nil, fmt.Errorf("loading bundle: %w", err)
+		}
+		env, err := b.Envelope()
+		if err != nil {
+			return nil, fmt.Errorf("bundle does not contain a DSSE envelope: %w", err)
+		}
+		return env.Envelope, nil
+	}
+
+	env := &dsse.Envelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, fmt.Errorf("decoding envelope: %w", err)
+	}
+	if env.PayloadType == "" || env.Payload == "" {
+		return nil, errors.New("attestation is not a DSSE envelope or a sigstore bundle")
+	}
+	return env, nil
+}
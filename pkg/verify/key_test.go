@@ -0,0 +1,159 @@
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	intotov1 "github.com/in-toto/attestation/go/v1"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+
+	"github.com/stacklok/trusty-attest/pkg/sign"
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// testKey is a generated ECDSA key written to PEM files
+type testKey struct {
+	private string
+	public  string
+}
+
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	k := &testKey{private: filepath.Join(dir, "key.pem"), public: filepath.Join(dir, "key.pub")}
+	for path, block := range map[string]*pem.Block{
+		k.private: {Type: "PRIVATE KEY", Bytes: priv},
+		k.public:  {Type: "PUBLIC KEY", Bytes: pub},
+	} {
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return k
+}
+
+// testStatement returns the encoding of a Trusty statement about app.tar.gz
+func testStatement(t *testing.T, pkg string) []byte {
+	t.Helper()
+	st, err := trusty.Attest(
+		[]*intotov1.ResourceDescriptor{{Name: "app.tar.gz", Digest: map[string]string{"sha256": strings.Repeat("a", 64)}}},
+		&trusty.Predicate{Packages: []trusty.PackageScore{{PackageInfo: trusty.PackageInfo{Package: pkg}, Score: 7.5}}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := trusty.CanonicalJSON(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// signStatement signs the statement with key and writes it to a file as
+// an envelope or a bundle. The payload is swapped for tamper after
+// signing, when set.
+func signStatement(t *testing.T, key string, asBundle bool, statement, tamper []byte) string {
+	t.Helper()
+	signer, err := sign.LoadKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := sign.Envelope(context.Background(), sign.PayloadType, statement, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tamper != nil {
+		env.Payload = base64.StdEncoding.EncodeToString(tamper)
+	}
+	var doc any = env
+	if asBundle {
+		doc, err = sign.Bundle(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := trusty.CanonicalJSON(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "attestation.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifyWithKey(t *testing.T) {
+	signer := newTestKey(t)
+	other := newTestKey(t)
+	statement := testStatement(t, "lodash")
+	tampered := testStatement(t, "evil")
+
+	for _, format := range []string{"envelope", "bundle"} {
+		asBundle := format == "bundle"
+		for _, tc := range []struct {
+			name   string
+			key    string
+			tamper []byte
+			err    string
+		}{
+			{"signed", signer.public, nil, ""},
+			{"wrong-key", other.public, nil, "verifying envelope signature"},
+			{"tampered", signer.public, tampered, "verifying envelope signature"},
+		} {
+			t.Run(format+"/"+tc.name, func(t *testing.T) {
+				path := signStatement(t, signer.private, asBundle, statement, tc.tamper)
+				res, err := Attestation(path, &Options{PublicKey: tc.key})
+				if tc.err != "" {
+					if err == nil || !strings.Contains(err.Error(), tc.err) {
+						t.Fatalf("expected error containing %q, got %v", tc.err, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("verifying: %v", err)
+				}
+				pub, err := os.ReadFile(signer.public)
+				if err != nil {
+					t.Fatal(err)
+				}
+				block, _ := pem.Decode(pub)
+				key, err := x509.ParsePKIXPublicKey(block.Bytes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				keyID, err := dsse.SHA256KeyID(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.KeyID != keyID {
+					t.Errorf("expected key id %s, got %s", keyID, res.KeyID)
+				}
+				if pkgs := res.Statement.Predicate.Packages; len(pkgs) != 1 || pkgs[0].Package != "lodash" {
+					t.Errorf("unexpected packages %v", pkgs)
+				}
+			})
+		}
+	}
+}
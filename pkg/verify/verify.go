@@ -0,0 +1,140 @@
+// Package verify checks the signatures of Trusty attestation bundles and
+// envelopes and extracts the statements they carry
+package verify
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+
+	"github.com/stacklok/trusty-attest/pkg/trusty"
+)
+
+// Options control how attestations are verified. Keyless bundles are
+// verified with the trusted root and certificate options, key signed
+// bundles and envelopes with the public key.
+type Options struct {
+	// PublicKey is the path to the PEM public key of the signer
+	PublicKey string
+	// TrustedRoot is the path to the sigstore trusted root JSON file
+	TrustedRoot string
+	// CertificateIdentity is the expected identity (SAN) of the signer
+	CertificateIdentity string
+	// CertificateIdentityRegexp matches the identity of the signer
+	CertificateIdentityRegexp string
+	// CertificateOIDCIssuer is the expected OIDC issuer of the signer
+	CertificateOIDCIssuer string
+	// LegacyBundle reads bundles in the shape written by older versions of
+	// trusty attest --bundle, see sign.DecodeLegacyBundle
+	LegacyBundle bool
+}
+
+// Validate checks the options
+func (o *Options) Validate() error {
+	if o.PublicKey != "" {
+		if o.TrustedRoot != "" || o.CertificateIdentity != "" || o.CertificateIdentityRegexp != "" || o.CertificateOIDCIssuer != "" {
+			return errors.New("a public key cannot be combined with the trusted root or certificate options")
+		}
+		return nil
+	}
+	errs := []error{}
+	if o.TrustedRoot == "" {
+		errs = append(errs, errors.New("a trusted root is required to verify bundles"))
+	}
+	if o.CertificateIdentity == "" && o.CertificateIdentityRegexp == "" {
+		errs = append(errs, errors.New("a certificate identity or identity regexp is required"))
+	}
+	if o.CertificateOIDCIssuer == "" {
+		errs = append(errs, errors.New("a certificate OIDC issuer is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// Result is a verified attestation
+type Result struct {
+	// Statement is the Trusty attestation signed in the bundle
+	Statement *trusty.Statement
+	// Verification is the sigstore verification result of keyless bundles
+	Verification *verify.VerificationResult
+	// KeyID is the id of the public key that verified a key signed
+	// attestation
+	KeyID string
+}
+
+// Attestation verifies the signed attestation at path, without contacting
+// any services. When the options set a public key, path can be a DSSE
+// envelope or a bundle signed with the key, otherwise it must be a keyless
+// sigstore bundle. Either way, the envelope must carry a Trusty statement.
+func Attestation(path string, opts *Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.PublicKey != "" {
+		return verifyWithKey(path, opts)
+	}
+	return verifyBundle(path, opts)
+}
+
+// verifyBundle verifies a keyless sigstore bundle against the trusted root
+// and signer identity in the options
+func verifyBundle(path string, opts *Options) (*Result, error) {
+	b, err := loadBundle(path, opts.LegacyBundle)
+	if err != nil {
+		return nil, fmt.Errorf("loading bundle: %w", err)
+	}
+
+	trustedRoot, err := root.NewTrustedRootFromPath(opts.TrustedRoot)
+	if err != nil {
+		return nil, fmt.Errorf("loading trusted root: %w", err)
+	}
+
+	verifier, err := verify.NewSignedEntityVerifier(
+		trustedRoot, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating verifier: %w", err)
+	}
+
+	identity, err := verify.NewShortCertificateIdentity(
+		opts.CertificateOIDCIssuer, opts.CertificateIdentity, "", opts.CertificateIdentityRegexp,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate identity: %w", err)
+	}
+
+	// Subjects are checked against the artifacts by the caller as statements
+	// may carry digests in more than one algorithm
+	vr, err := verifier.Verify(b, verify.NewPolicy(
+		verify.WithoutArtifactUnsafe(), verify.WithCertificateIdentity(identity),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("verifying bundle: %w", err)
+	}
+
+	env, err := b.Envelope()
+	if err != nil {
+		return nil, fmt.Errorf("bundle does not contain a DSSE envelope: %w", err)
+	}
+	st, err := envelopeStatement(env.Envelope)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Statement: st, Verification: vr}, nil
+}
+
+// envelopeStatement decodes the Trusty statement in a DSSE envelope
+func envelopeStatement(env *dsse.Envelope) (*trusty.Statement, error) {
+	if env.PayloadType != bundle.IntotoMediaType {
+		return nil, fmt.Errorf("unsupported envelope payload type %q", env.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding envelope payload: %w", err)
+	}
+	return trusty.ParseStatement(payload)
+}